@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// cancelOnSignal returns a context which is cancelled when the step receives SIGINT or SIGTERM,
+// e.g. because the parent build was aborted or timed out.
+// Calling the returned cancel function restores the default handling of the signals, which terminates the step.
+func cancelOnSignal() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	go func() {
+		defer signal.Stop(signals)
+		select {
+		case sig := <-signals:
+			logger.Warnf("Received %s signal", sig)
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return ctx, func() {
+		signal.Stop(signals)
+		cancel()
+	}
+}
+
+// abortBuilds aborts every build (or pipeline) which has not finished yet.
+// Reused builds were started by someone else, so they are left running.
+func abortBuilds(client apiClient, builds []TriggeredBuildModel, reason string) {
+	for _, build := range builds {
+		if build.Status != "" {
+			continue
+		}
+		if build.Reused {
+			logger.Infof("Not aborting build %s (%s), it was not started by this step", build.BuildSlug, build.WorkflowID)
+			continue
+		}
+
+		if build.PipelineID != "" {
+			logger.Warnf("Aborting pipeline %s (%s)", build.PipelineID, build.Pipeline)
+			if err := abortPipeline(client, build.AppSlug, build.PipelineID, reason); err != nil {
+				logger.Errorf("Could not abort pipeline %s, error: %s", build.PipelineID, err)
+			}
+			continue
+		}
+
+		logger.Warnf("Aborting build %s (%s)", build.BuildSlug, build.WorkflowID)
+		if err := abortBuild(client, build.AppSlug, build.BuildSlug, reason); err != nil {
+			logger.Errorf("Could not abort build %s, error: %s", build.BuildSlug, err)
+		}
+	}
+}
+
+func abortBuild(client apiClient, appSlug, buildSlug, reason string) error {
+	requestModel := AbortRequestModel{
+		AbortReason:       reason,
+		AbortWithSuccess:  false,
+		SkipNotifications: true,
+	}
+	return client.sendRESTRequest(context.Background(), "POST", fmt.Sprintf("/v0.1/apps/%s/builds/%s/abort", appSlug, buildSlug), requestModel, nil)
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAbortBuildsSkipsFinishedBuilds(t *testing.T) {
+	abortedPaths := []string{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		abortedPaths = append(abortedPaths, r.URL.Path)
+		require.Equal(t, "access", r.Header.Get("Authorization"))
+
+		var requestModel AbortRequestModel
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestModel))
+		require.Equal(t, "parent aborted", requestModel.AbortReason)
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access"})
+	require.NoError(t, err)
+	builds := []TriggeredBuildModel{
+		{AppSlug: "app", BuildSlug: "running"},
+		{AppSlug: "app", BuildSlug: "finished", Status: buildStatusSuccess},
+	}
+	abortBuilds(client, builds, "parent aborted")
+
+	require.Equal(t, []string{"/v0.1/apps/app/builds/running/abort"}, abortedPaths)
+}
+
+func TestAbortBuildsSkipsReusedBuildsOnInterrupt(t *testing.T) {
+	abortedPaths := []string{}
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		if strings.HasSuffix(r.URL.Path, "/abort") {
+			abortedPaths = append(abortedPaths, r.URL.Path)
+			return
+		}
+		_, err := w.Write([]byte(`{"data":{"status":0}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", AppSlug: "app", PollInterval: "60", RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	builds := []TriggeredBuildModel{{AppSlug: "app", BuildSlug: "started"}, {AppSlug: "app", BuildSlug: "reused", Reused: true}}
+	waitedBuilds, err := waitForBuilds(ctx, client, configs, builds)
+	require.Error(t, err)
+	abortBuilds(client, updateBuildStatuses(builds, waitedBuilds), "parent aborted")
+
+	require.Equal(t, []string{"/v0.1/apps/app/builds/started/abort"}, abortedPaths)
+}
+
+func TestCancelOnSignalCancel(t *testing.T) {
+	ctx, cancel := cancelOnSignal()
+	require.NoError(t, ctx.Err())
+
+	cancel()
+	cancel()
+	require.Equal(t, context.Canceled, ctx.Err())
+}
@@ -0,0 +1,127 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+)
+
+const (
+	defaultAppBaseURL = "https://app.bitrise.io"
+	defaultAPIBaseURL = "https://api.bitrise.io"
+
+	apiProtocolLegacy = "legacy"
+	apiProtocolREST   = "rest"
+)
+
+// apiClient ...
+type apiClient struct {
+	appBaseURL  string
+	apiBaseURL  string
+	accessToken string
+	retryCount  int
+	httpClient  *http.Client
+	// secrets are redacted from the responses quoted in errors.
+	secrets []string
+}
+
+// newAPIClient creates a client for the Bitrise APIs. If a base URL is configured, every API call goes through it,
+// otherwise the build trigger API is called on app.bitrise.io and the REST API on api.bitrise.io.
+// Every request is sent with the same HTTP client.
+func newAPIClient(configs ConfigsModel) (apiClient, error) {
+	httpClient, err := newHTTPClient(configs)
+	if err != nil {
+		return apiClient{}, err
+	}
+
+	client := apiClient{
+		appBaseURL:  defaultAppBaseURL,
+		apiBaseURL:  defaultAPIBaseURL,
+		accessToken: configs.AccessToken,
+		retryCount:  configs.retryCount(),
+		httpClient:  httpClient,
+		secrets:     configs.secretValues(),
+	}
+	if configs.APIBaseURL != "" {
+		baseURL := strings.TrimSuffix(configs.APIBaseURL, "/")
+		client.appBaseURL = baseURL
+		client.apiBaseURL = baseURL
+	}
+	return client, nil
+}
+
+func (client apiClient) triggerURL(appSlug string) string {
+	return fmt.Sprintf("%s/app/%s/build/start.json", client.appBaseURL, appSlug)
+}
+
+func (client apiClient) restTriggerURL(appSlug string) string {
+	return fmt.Sprintf("%s/v0.1/apps/%s/builds", client.apiBaseURL, appSlug)
+}
+
+func (client apiClient) buildURL(buildSlug string) string {
+	return fmt.Sprintf("%s/build/%s", client.appBaseURL, buildSlug)
+}
+
+func (client apiClient) pipelineURL(appSlug, pipelineID string) string {
+	return fmt.Sprintf("%s/app/%s/pipelines/%s", client.appBaseURL, appSlug, pipelineID)
+}
+
+// sendRESTRequest calls the REST API endpoint at path and decodes the JSON response into responseModel, if not nil.
+func (client apiClient) sendRESTRequest(ctx context.Context, method, path string, requestModel, responseModel interface{}) error {
+	var body []byte
+	if requestModel != nil {
+		var err error
+		if body, err = json.Marshal(requestModel); err != nil {
+			return err
+		}
+	}
+
+	_, err := client.doRESTRequest(ctx, method, path, body, responseModel)
+	return err
+}
+
+// doRESTRequest calls the REST API endpoint at path with the JSON body, if not nil, and returns the response body.
+// If responseModel is not nil, the body is decoded into it as JSON.
+func (client apiClient) doRESTRequest(ctx context.Context, method, path string, body []byte, responseModel interface{}) ([]byte, error) {
+	request, err := http.NewRequest(method, client.apiBaseURL+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	request.Header.Add("Authorization", client.accessToken)
+	if body != nil {
+		request.Header.Add("Content-Type", "application/json")
+	}
+
+	response, err := doWithRetry(client.httpClient, request, client.retryCount)
+	if err != nil {
+		return nil, err
+	}
+
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			logger.Warnf("Failed to close response body, error: %s", err)
+		}
+	}()
+
+	contents, err := ioutil.ReadAll(response.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return nil, newResponseError(request, response, contents, client.secrets)
+	}
+
+	if responseModel != nil {
+		if err := json.Unmarshal(contents, responseModel); err != nil {
+			return nil, newNonJSONResponseError(request, response, contents, client.secrets, err)
+		}
+	}
+	return contents, nil
+}
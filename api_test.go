@@ -0,0 +1,42 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsInvalidAPIBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"app.bitrise.io", "ftp://app.bitrise.io", "/relative/path", "https://"} {
+		configs := ConfigsModel{
+			APIToken:   "token",
+			AppSlug:    "slug",
+			APIBaseURL: baseURL,
+		}
+		require.EqualError(t, configs.validate(), fmt.Sprintf("invalid API base URL specified: %s, must be an absolute http or https URL", baseURL))
+	}
+}
+
+func TestValidateConfigsValidAPIBaseURL(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		APIBaseURL: "http://localhost:8080/",
+	}
+	require.NoError(t, configs.validate())
+}
+
+func TestNewAPIClientDefaultBaseURLs(t *testing.T) {
+	client, err := newAPIClient(ConfigsModel{})
+	require.NoError(t, err)
+	require.Equal(t, "https://app.bitrise.io/app/slug/build/start.json", client.triggerURL("slug"))
+	require.Equal(t, defaultAPIBaseURL, client.apiBaseURL)
+}
+
+func TestNewAPIClientCustomBaseURL(t *testing.T) {
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: "http://localhost:8080/"})
+	require.NoError(t, err)
+	require.Equal(t, "http://localhost:8080/app/slug/build/start.json", client.triggerURL("slug"))
+	require.Equal(t, "http://localhost:8080", client.apiBaseURL)
+}
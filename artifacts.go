@@ -0,0 +1,176 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// downloadArtifacts downloads the artifacts of every finished build whose title matches one of the patterns
+// and returns the local paths. Artifacts of multiple builds are separated into subdirectories named after the build slugs.
+// Artifacts of a build sharing a title get the artifact slug appended to their file name.
+func downloadArtifacts(client apiClient, builds []TriggeredBuildModel, patterns []string, targetDir string) ([]string, error) {
+	paths := []string{}
+
+	for _, build := range builds {
+		if build.Status == "" {
+			continue
+		}
+
+		buildDir := targetDir
+		if len(builds) > 1 {
+			buildDir = filepath.Join(targetDir, build.BuildSlug)
+		}
+
+		artifacts, err := listArtifacts(client, build.AppSlug, build.BuildSlug)
+		if err != nil {
+			return paths, fmt.Errorf("could not list artifacts of build %s, error: %s", build.BuildSlug, err)
+		}
+
+		matchingArtifacts := []ArtifactModel{}
+		for _, artifact := range artifacts {
+			if matchesAnyPattern(artifact.Title, patterns) {
+				matchingArtifacts = append(matchingArtifacts, artifact)
+			}
+		}
+
+		fileNames := artifactFileNames(matchingArtifacts)
+		for _, artifact := range matchingArtifacts {
+			path, err := downloadArtifact(client, build.AppSlug, build.BuildSlug, artifact, filepath.Join(buildDir, fileNames[artifact.Slug]))
+			if err != nil {
+				return paths, fmt.Errorf("could not download artifact %s of build %s, error: %s", artifact.Title, build.BuildSlug, err)
+			}
+			logger.Printf("Downloaded artifact %s of build %s to %s", artifact.Title, build.BuildSlug, path)
+			paths = append(paths, path)
+		}
+	}
+
+	return paths, nil
+}
+
+func listArtifacts(client apiClient, appSlug, buildSlug string) ([]ArtifactModel, error) {
+	artifacts := []ArtifactModel{}
+	next := ""
+
+	for {
+		path := fmt.Sprintf("/v0.1/apps/%s/builds/%s/artifacts", appSlug, buildSlug)
+		if next != "" {
+			path += "?next=" + url.QueryEscape(next)
+		}
+
+		var responseModel ArtifactListResponseModel
+		if err := client.sendRESTRequest(context.Background(), "GET", path, nil, &responseModel); err != nil {
+			return artifacts, err
+		}
+		artifacts = append(artifacts, responseModel.Data...)
+
+		next = responseModel.Paging.Next
+		if next == "" {
+			return artifacts, nil
+		}
+	}
+}
+
+// artifactFileNames returns the file name of each artifact by its slug. Artifacts sharing a title
+// get the artifact slug appended to their name, so they do not overwrite each other.
+func artifactFileNames(artifacts []ArtifactModel) map[string]string {
+	titleCounts := map[string]int{}
+	for _, artifact := range artifacts {
+		titleCounts[artifactBaseName(artifact)]++
+	}
+
+	fileNames := map[string]string{}
+	for _, artifact := range artifacts {
+		fileName := artifactBaseName(artifact)
+		if titleCounts[fileName] > 1 {
+			extension := filepath.Ext(fileName)
+			fileName = strings.TrimSuffix(fileName, extension) + "-" + artifact.Slug + extension
+		}
+		fileNames[artifact.Slug] = fileName
+	}
+	return fileNames
+}
+
+// artifactBaseName returns the last element of the artifact title, or the artifact slug
+// if the title does not name a file (e.g. it is empty or `..`), so the download stays inside the artifacts directory.
+func artifactBaseName(artifact ArtifactModel) string {
+	name := filepath.Base(artifact.Title)
+	switch name {
+	case ".", "..", string(filepath.Separator):
+		return artifact.Slug
+	}
+	return name
+}
+
+func downloadArtifact(client apiClient, appSlug, buildSlug string, artifact ArtifactModel, path string) (string, error) {
+	var responseModel ArtifactResponseModel
+	if err := client.sendRESTRequest(context.Background(), "GET", fmt.Sprintf("/v0.1/apps/%s/builds/%s/artifacts/%s", appSlug, buildSlug, artifact.Slug), nil, &responseModel); err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return "", err
+	}
+
+	request, err := http.NewRequest("GET", responseModel.Data.ExpiringDownloadURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := doWithRetry(client.httpClient, request, client.retryCount)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			logger.Warnf("Failed to close response body, error: %s", err)
+		}
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("download failed, status: %s", response.Status)
+	}
+
+	size, err := writeFile(path, response.Body)
+	if err != nil {
+		return "", err
+	}
+
+	if size != artifact.FileSizeBytes {
+		if err := os.Remove(path); err != nil {
+			logger.Warnf("Failed to remove incomplete artifact %s, error: %s", path, err)
+		}
+		return "", fmt.Errorf("downloaded %d bytes, expected %d", size, artifact.FileSizeBytes)
+	}
+
+	return path, nil
+}
+
+func writeFile(path string, reader io.Reader) (int64, error) {
+	file, err := os.Create(path)
+	if err != nil {
+		return 0, err
+	}
+
+	size, err := io.Copy(file, reader)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return size, err
+}
+
+func matchesAnyPattern(name string, patterns []string) bool {
+	for _, pattern := range patterns {
+		if matched, err := filepath.Match(pattern, name); err == nil && matched {
+			return true
+		}
+	}
+	return false
+}
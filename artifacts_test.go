@@ -0,0 +1,101 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsDownloadArtifactsWithoutWait(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		DownloadArtifacts: "yes",
+		ArtifactsDir:      "deploy",
+	}
+	require.EqualError(t, configs.validate(), "artifacts can be downloaded only when waiting for the triggered build")
+}
+
+func TestValidateConfigsInvalidArtifactPattern(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WaitForBuild:      "yes",
+		AccessToken:       "access",
+		PollInterval:      "10",
+		DownloadArtifacts: "yes",
+		ArtifactsDir:      "deploy",
+		ArtifactPatterns:  "*.apk|[",
+	}
+	require.EqualError(t, configs.validate(), "invalid artifact pattern specified: [")
+}
+
+func TestMatchesAnyPattern(t *testing.T) {
+	require.True(t, matchesAnyPattern("app-release.apk", []string{"*.ipa", "*.apk"}))
+	require.False(t, matchesAnyPattern("coverage.zip", []string{"*.apk"}))
+}
+
+func TestDownloadArtifacts(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.URL.Path {
+		case "/v0.1/apps/app/builds/build/artifacts":
+			_, err = w.Write([]byte(`{"data":[{"slug":"apk","title":"app.apk","file_size_bytes":7},{"slug":"log","title":"build.log","file_size_bytes":3}],"paging":{}}`))
+		case "/v0.1/apps/app/builds/build/artifacts/apk":
+			_, err = w.Write([]byte(`{"data":{"slug":"apk","title":"app.apk","expiring_download_url":"` + server.URL + `/download/apk"}}`))
+		case "/download/apk":
+			_, err = w.Write([]byte("content"))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	targetDir, err := ioutil.TempDir("", "artifacts")
+	require.NoError(t, err)
+	defer func() {
+		require.NoError(t, os.RemoveAll(targetDir))
+	}()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access"})
+	require.NoError(t, err)
+	builds := []TriggeredBuildModel{{AppSlug: "app", BuildSlug: "build", Status: buildStatusSuccess}}
+	paths, err := downloadArtifacts(client, builds, []string{"*.apk"}, targetDir)
+	require.NoError(t, err)
+	require.Equal(t, []string{filepath.Join(targetDir, "app.apk")}, paths)
+
+	content, err := ioutil.ReadFile(paths[0])
+	require.NoError(t, err)
+	require.Equal(t, "content", string(content))
+}
+
+func TestArtifactFileNames(t *testing.T) {
+	require.Equal(t, map[string]string{
+		"first":  "app-first.apk",
+		"second": "app-second.apk",
+		"log":    "build.log",
+		"empty":  "empty",
+		"dot":    "dot",
+		"parent": "parent",
+		"root":   "root",
+		"nested": "nested",
+		"dir":    "logs",
+	}, artifactFileNames([]ArtifactModel{
+		{Slug: "first", Title: "app.apk"},
+		{Slug: "second", Title: "app.apk"},
+		{Slug: "log", Title: "logs/build.log"},
+		{Slug: "empty", Title: ""},
+		{Slug: "dot", Title: "."},
+		{Slug: "parent", Title: ".."},
+		{Slug: "root", Title: "/"},
+		{Slug: "nested", Title: "logs/.."},
+		{Slug: "dir", Title: "logs/"},
+	}))
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+const (
+	buildLogModeAlways    = "always"
+	buildLogModeOnFailure = "on_failure"
+	buildLogModeNever     = "never"
+
+	defaultBuildLogLines = 100
+)
+
+// buildLogTailer prints the log of a running build incrementally. Chunks already printed are skipped by their position.
+type buildLogTailer struct {
+	client    apiClient
+	appSlug   string
+	buildSlug string
+	name      string
+
+	lastPosition   int
+	afterTimestamp string
+	printedLines   int
+	partialLine    string
+}
+
+func newBuildLogTailer(client apiClient, build TriggeredBuildModel) *buildLogTailer {
+	return &buildLogTailer{
+		client:       client,
+		appSlug:      build.AppSlug,
+		buildSlug:    build.BuildSlug,
+		name:         buildDisplayName(build),
+		lastPosition: -1,
+	}
+}
+
+// printNewChunks prints the log chunks which were not printed yet.
+func (tailer *buildLogTailer) printNewChunks(ctx context.Context) error {
+	buildLog, err := fetchBuildLog(ctx, tailer.client, tailer.appSlug, tailer.buildSlug, tailer.afterTimestamp)
+	if err != nil {
+		return err
+	}
+
+	tailer.printChunks(buildLog)
+	return nil
+}
+
+func (tailer *buildLogTailer) printChunks(buildLog BuildLogResponseModel) {
+	for _, chunk := range sortedLogChunks(buildLog.LogChunks) {
+		if chunk.Position <= tailer.lastPosition {
+			continue
+		}
+		tailer.lastPosition = chunk.Position
+		tailer.print(chunk.Chunk)
+	}
+
+	if buildLog.NextAfterTimestamp != "" {
+		tailer.afterTimestamp = buildLog.NextAfterTimestamp
+	}
+}
+
+// finish prints the rest of the log of a finished build.
+// Once the log is archived, the chunks are no longer available, so the lines which were not printed yet are taken from the raw log.
+func (tailer *buildLogTailer) finish(ctx context.Context) error {
+	buildLog, err := fetchBuildLog(ctx, tailer.client, tailer.appSlug, tailer.buildSlug, tailer.afterTimestamp)
+	if err != nil {
+		return err
+	}
+
+	if len(buildLog.LogChunks) == 0 && buildLog.IsArchived && buildLog.ExpiringRawLogURL != "" {
+		rawLog, err := downloadRawBuildLog(tailer.client, buildLog.ExpiringRawLogURL)
+		if err != nil {
+			return err
+		}
+
+		// The partial line is printed again as part of the raw log.
+		lines := strings.SplitAfter(rawLog, "\n")
+		tailer.partialLine = ""
+		if tailer.printedLines < len(lines) {
+			tailer.print(strings.Join(lines[tailer.printedLines:], ""))
+		}
+	} else {
+		tailer.printChunks(buildLog)
+	}
+
+	if tailer.partialLine != "" {
+		printBuildLogLine(tailer.name, tailer.partialLine)
+		tailer.partialLine = ""
+	}
+	return nil
+}
+
+// print prints the complete lines of text and keeps the trailing partial line until the next chunk arrives.
+func (tailer *buildLogTailer) print(text string) {
+	lines := strings.Split(tailer.partialLine+text, "\n")
+	for _, line := range lines[:len(lines)-1] {
+		printBuildLogLine(tailer.name, strings.TrimSuffix(line, "\r"))
+		tailer.printedLines++
+	}
+	tailer.partialLine = lines[len(lines)-1]
+}
+
+// printBuildLogTail prints the last lineCount lines of the log of a finished build.
+func printBuildLogTail(client apiClient, build TriggeredBuildModel, lineCount int) error {
+	buildLog, err := fetchBuildLog(context.Background(), client, build.AppSlug, build.BuildSlug, "")
+	if err != nil {
+		return err
+	}
+
+	var fullLog string
+	if buildLog.IsArchived && buildLog.ExpiringRawLogURL != "" {
+		if fullLog, err = downloadRawBuildLog(client, buildLog.ExpiringRawLogURL); err != nil {
+			return err
+		}
+	} else {
+		for _, chunk := range sortedLogChunks(buildLog.LogChunks) {
+			fullLog += chunk.Chunk
+		}
+	}
+
+	name := buildDisplayName(build)
+	for _, line := range lastLines(fullLog, lineCount) {
+		printBuildLogLine(name, line)
+	}
+	return nil
+}
+
+func fetchBuildLog(ctx context.Context, client apiClient, appSlug, buildSlug, timestamp string) (BuildLogResponseModel, error) {
+	path := fmt.Sprintf("/v0.1/apps/%s/builds/%s/log", appSlug, buildSlug)
+	if timestamp != "" {
+		path += "?timestamp=" + url.QueryEscape(timestamp)
+	}
+
+	var responseModel BuildLogResponseModel
+	err := client.sendRESTRequest(ctx, "GET", path, nil, &responseModel)
+	return responseModel, err
+}
+
+func downloadRawBuildLog(client apiClient, rawLogURL string) (string, error) {
+	request, err := http.NewRequest("GET", rawLogURL, nil)
+	if err != nil {
+		return "", err
+	}
+
+	response, err := doWithRetry(client.httpClient, request, client.retryCount)
+	if err != nil {
+		return "", err
+	}
+
+	defer func() {
+		err := response.Body.Close()
+		if err != nil {
+			logger.Warnf("Failed to close response body, error: %s", err)
+		}
+	}()
+
+	if response.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("raw log download failed, status: %s", response.Status)
+	}
+
+	contents, err := ioutil.ReadAll(response.Body)
+	return string(contents), err
+}
+
+func sortedLogChunks(chunks []LogChunkModel) []LogChunkModel {
+	sorted := append([]LogChunkModel{}, chunks...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Position < sorted[j].Position
+	})
+	return sorted
+}
+
+// lastLines returns at most lineCount last lines of text, ignoring the trailing newline.
+func lastLines(text string, lineCount int) []string {
+	text = strings.TrimSuffix(text, "\n")
+	if text == "" {
+		return []string{}
+	}
+
+	lines := strings.Split(text, "\n")
+	if len(lines) > lineCount {
+		lines = lines[len(lines)-lineCount:]
+	}
+	return lines
+}
+
+// printBuildLogLine prints a line of the log of a triggered build, prefixed with the build name.
+func printBuildLogLine(buildName, line string) {
+	logger.Event(logLevelNormal, "build_log", logFields{"build": buildName, "line": line}, "[%s] %s", buildName, line)
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsInvalidBuildLogMode(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		BuildLogMode: "sometimes",
+	}
+	require.EqualError(t, configs.validate(), "invalid build log mode specified: sometimes, allowed: always, on_failure, never")
+}
+
+func TestLastLines(t *testing.T) {
+	require.Equal(t, []string{"b", "c"}, lastLines("a\nb\nc\n", 2))
+	require.Equal(t, []string{"a", "b"}, lastLines("a\nb", 5))
+	require.Equal(t, []string{}, lastLines("", 5))
+}
+
+func TestBuildLogTailerSkipsPrintedChunks(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		var err error
+		if requests == 1 {
+			require.Equal(t, "", r.URL.Query().Get("timestamp"))
+			_, err = w.Write([]byte(`{"log_chunks":[{"chunk":"first\nsec","position":0}],"next_after_timestamp":"t1"}`))
+		} else {
+			require.Equal(t, "t1", r.URL.Query().Get("timestamp"))
+			_, err = w.Write([]byte(`{"log_chunks":[{"chunk":"first\nsec","position":0},{"chunk":"ond\n","position":1}],"next_after_timestamp":"t2"}`))
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access"})
+	require.NoError(t, err)
+	tailer := newBuildLogTailer(client, TriggeredBuildModel{AppSlug: "app", BuildSlug: "build", WorkflowID: "lint"})
+
+	require.NoError(t, tailer.printNewChunks(context.Background()))
+	require.Equal(t, "sec", tailer.partialLine)
+	require.NoError(t, tailer.printNewChunks(context.Background()))
+	require.Equal(t, 1, tailer.lastPosition)
+	require.Equal(t, "", tailer.partialLine)
+	require.Equal(t, 2, tailer.printedLines)
+}
+
+func TestBuildLogTailerFinishArchivedLog(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var err error
+		switch r.URL.Path {
+		case "/v0.1/apps/app/builds/build/log":
+			if r.URL.Query().Get("timestamp") == "" {
+				_, err = w.Write([]byte(`{"log_chunks":[{"chunk":"első\nmáso","position":0}],"next_after_timestamp":"t1"}`))
+			} else {
+				_, err = w.Write([]byte(`{"log_chunks":[],"is_archived":true,"expiring_raw_log_url":"` + server.URL + `/raw"}`))
+			}
+		case "/raw":
+			_, err = w.Write([]byte("első\nmásodik\nharmadik"))
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	var buffer bytes.Buffer
+	originalLogger := logger
+	logger = jsonLogger{logger: *log.NewJSONLoger(&buffer)}
+	defer func() { logger = originalLogger }()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", RetryCount: "0"})
+	require.NoError(t, err)
+	tailer := newBuildLogTailer(client, TriggeredBuildModel{AppSlug: "app", BuildSlug: "build", WorkflowID: "lint"})
+
+	require.NoError(t, tailer.printNewChunks(context.Background()))
+	require.NoError(t, tailer.finish(context.Background()))
+
+	printed := []string{}
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		var event map[string]interface{}
+		require.NoError(t, json.Unmarshal([]byte(line), &event))
+		if event["event"] == "build_log" {
+			printed = append(printed, event["data"].(map[string]interface{})["line"].(string))
+		}
+	}
+	require.Equal(t, []string{"első", "második", "harmadik"}, printed)
+}
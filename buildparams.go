@@ -0,0 +1,98 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+const (
+	commitPathAdded    = "added"
+	commitPathRemoved  = "removed"
+	commitPathModified = "modified"
+
+	minPriority = -100
+	maxPriority = 100
+)
+
+// parseCommitPaths parses `|` separated `[<change>:]<path>` entries, where change is added, removed or modified (the default).
+// The paths are sent as a single commit, which is enough for the path filters of the Trigger Map.
+func parseCommitPaths(input string) ([]CommitPathsModel, error) {
+	entries := splitPipeSeparatedStringArray(input)
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	commit := CommitPathsModel{}
+	for _, entry := range entries {
+		change, path := commitPathModified, strings.TrimSpace(entry)
+		if parts := strings.SplitN(path, ":", 2); len(parts) == 2 {
+			change, path = strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1])
+		}
+		if path == "" {
+			return nil, fmt.Errorf("empty path specified in commit paths: %s", entry)
+		}
+
+		switch change {
+		case commitPathAdded:
+			commit.Added = append(commit.Added, path)
+		case commitPathRemoved:
+			commit.Removed = append(commit.Removed, path)
+		case commitPathModified:
+			commit.Modified = append(commit.Modified, path)
+		default:
+			return nil, fmt.Errorf("invalid change type specified in commit paths: %s, allowed: %s, %s, %s", change, commitPathAdded, commitPathRemoved, commitPathModified)
+		}
+	}
+	return []CommitPathsModel{commit}, nil
+}
+
+// priority returns the build priority, 0 (the default of Bitrise) if not specified.
+func (configs ConfigsModel) priority() (int, error) {
+	if configs.Priority == "" {
+		return 0, nil
+	}
+
+	priority, err := strconv.Atoi(configs.Priority)
+	if err != nil || priority < minPriority || priority > maxPriority {
+		return 0, fmt.Errorf("invalid priority specified: %s, must be an integer between %d and %d", configs.Priority, minPriority, maxPriority)
+	}
+	return priority, nil
+}
+
+// validateBuildParams validates the optional build parameters which are passed to the triggered build as they are.
+func (configs ConfigsModel) validateBuildParams() error {
+	if err := validateYesNo("skip git status report", configs.SkipGitStatusReport); err != nil {
+		return err
+	}
+
+	if _, err := parseCommitPaths(configs.CommitPaths); err != nil {
+		return err
+	}
+
+	if configs.DiffURL != "" {
+		diffURL, err := url.Parse(configs.DiffURL)
+		if err != nil || (diffURL.Scheme != "http" && diffURL.Scheme != "https") || diffURL.Host == "" {
+			return fmt.Errorf("invalid diff URL specified: %s, must be an absolute http or https URL", configs.DiffURL)
+		}
+	}
+
+	if configs.MachineTypeID != "" && strings.ContainsAny(configs.MachineTypeID, " |") {
+		return fmt.Errorf("invalid machine type ID specified: %s", configs.MachineTypeID)
+	}
+
+	if configs.StackID != "" && strings.ContainsAny(configs.StackID, " |") {
+		return fmt.Errorf("invalid stack ID specified: %s", configs.StackID)
+	}
+
+	if _, err := configs.priority(); err != nil {
+		return err
+	}
+
+	if configs.PullRequestUnverifiedMergeBranch != "" && configs.PullRequestID == "" {
+		return errors.New("unverified merge branch specified without a Pull Request ID")
+	}
+	return nil
+}
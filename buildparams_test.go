@@ -0,0 +1,104 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseCommitPaths(t *testing.T) {
+	commitPaths, err := parseCommitPaths("added:docs/new.md|src/main.go|removed: old.go|modified:README.md")
+	require.NoError(t, err)
+	require.Equal(t, []CommitPathsModel{{
+		Added:    []string{"docs/new.md"},
+		Removed:  []string{"old.go"},
+		Modified: []string{"src/main.go", "README.md"},
+	}}, commitPaths)
+
+	commitPaths, err = parseCommitPaths("")
+	require.NoError(t, err)
+	require.Nil(t, commitPaths)
+
+	_, err = parseCommitPaths("renamed:src/main.go")
+	require.Error(t, err)
+
+	_, err = parseCommitPaths("added:")
+	require.Error(t, err)
+}
+
+func TestValidateConfigsBuildParams(t *testing.T) {
+	for expectedError, configs := range map[string]ConfigsModel{
+		"invalid skip git status report value specified: true, allowed: yes, no":                    {SkipGitStatusReport: "true"},
+		"invalid priority specified: high, must be an integer between -100 and 100":                 {Priority: "high"},
+		"invalid priority specified: 101, must be an integer between -100 and 100":                  {Priority: "101"},
+		"invalid priority specified: -101, must be an integer between -100 and 100":                 {Priority: "-101"},
+		"invalid diff URL specified: diff.patch, must be an absolute http or https URL":             {DiffURL: "diff.patch"},
+		"invalid change type specified in commit paths: renamed, allowed: added, removed, modified": {CommitPaths: "renamed:main.go"},
+		"invalid machine type ID specified: g2 m1":                                                  {MachineTypeID: "g2 m1"},
+		"unverified merge branch specified without a Pull Request ID":                               {PullRequestUnverifiedMergeBranch: "pull/1/merge"},
+	} {
+		configs.APIToken = "token"
+		configs.AppSlug = "slug"
+		require.EqualError(t, configs.validate(), expectedError)
+	}
+
+	configs := ConfigsModel{
+		APIToken:                         "token",
+		AppSlug:                          "slug",
+		SkipGitStatusReport:              "yes",
+		Priority:                         "-100",
+		DiffURL:                          "https://github.com/org/repo/pull/1.diff",
+		CommitPaths:                      "added:main.go",
+		MachineTypeID:                    "g2-m1.8core",
+		StackID:                          "osx-xcode-15.0.x",
+		PullRequestID:                    "1",
+		PullRequestUnverifiedMergeBranch: "pull/1/merge",
+	}
+	require.NoError(t, configs.validate())
+}
+
+func TestCreateRequestBodyFromConfigsBuildParams(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:                         "token",
+		SkipGitStatusReport:              "yes",
+		CommitPaths:                      "added:main.go",
+		DiffURL:                          "https://github.com/org/repo/pull/1.diff",
+		BaseRepositoryURL:                "https://github.com/org/repo.git",
+		HeadRepositoryURL:                "https://github.com/fork/repo.git",
+		PullRequestAuthor:                "octocat",
+		PullRequestUnverifiedMergeBranch: "pull/1/merge",
+		StackID:                          "osx-xcode-15.0.x",
+		MachineTypeID:                    "g2-m1.8core",
+		Priority:                         "50",
+	}
+	body, err := createRequestBodyFromConfigs(configs, TriggerTargetModel{APIToken: "token", WorkflowID: "lint"})
+	require.NoError(t, err)
+
+	var requestModel RequestModel
+	require.NoError(t, json.Unmarshal(body, &requestModel))
+	require.Equal(t, BuildParamsModel{
+		WorkflowID:                       "lint",
+		Environments:                     requestModel.BuildParams.Environments,
+		SkipGitStatusReport:              true,
+		CommitPaths:                      []CommitPathsModel{{Added: []string{"main.go"}}},
+		DiffURL:                          "https://github.com/org/repo/pull/1.diff",
+		BaseRepositoryURL:                "https://github.com/org/repo.git",
+		HeadRepositoryURL:                "https://github.com/fork/repo.git",
+		PullRequestAuthor:                "octocat",
+		PullRequestUnverifiedMergeBranch: "pull/1/merge",
+		StackID:                          "osx-xcode-15.0.x",
+		MachineTypeID:                    "g2-m1.8core",
+		Priority:                         50,
+	}, requestModel.BuildParams)
+}
+
+func TestCreateRequestBodyFromConfigsOmitsEmptyBuildParams(t *testing.T) {
+	body, err := createRequestBodyFromConfigs(ConfigsModel{APIToken: "token"}, TriggerTargetModel{APIToken: "token", WorkflowID: "lint"})
+	require.NoError(t, err)
+
+	for _, key := range []string{"skip_git_status_report", "commit_paths", "diff_url", "base_repository_url", "head_repository_url",
+		"pull_request_author", "pull_request_unverified_merge_branch", "stack_id", "machine_type_id", "priority", "pipeline_id"} {
+		require.NotContains(t, string(body), `"`+key+`"`)
+	}
+}
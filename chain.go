@@ -0,0 +1,82 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+const (
+	triggerChainEnvKey     = "BITRISE_TRIGGER_CHAIN"
+	defaultMaxTriggerDepth = 5
+)
+
+// TriggerChainModel describes the builds which led to the current build through this step.
+// It is passed to the triggered builds in the BITRISE_TRIGGER_CHAIN environment variable.
+type TriggerChainModel struct {
+	Depth  int                      `json:"depth"`
+	Builds []TriggerChainBuildModel `json:"builds"`
+}
+
+// TriggerChainBuildModel identifies a build of the chain by its app and workflow,
+// so the same workflow of a different app does not count as a recursive trigger.
+type TriggerChainBuildModel struct {
+	AppSlug    string `json:"app_slug"`
+	WorkflowID string `json:"workflow_id"`
+}
+
+func parseTriggerChain(value string) (TriggerChainModel, error) {
+	chain := TriggerChainModel{Builds: []TriggerChainBuildModel{}}
+	if value == "" {
+		return chain, nil
+	}
+
+	if err := json.Unmarshal([]byte(value), &chain); err != nil {
+		return chain, fmt.Errorf("invalid %s value: %s", triggerChainEnvKey, err)
+	}
+	if chain.Builds == nil {
+		chain.Builds = []TriggerChainBuildModel{}
+	}
+	return chain, nil
+}
+
+// next returns the chain of the builds triggered by the current build.
+func (chain TriggerChainModel) next(currentAppSlug, currentWorkflowID string) TriggerChainModel {
+	builds := append([]TriggerChainBuildModel{}, chain.Builds...)
+	if currentWorkflowID != "" {
+		builds = append(builds, TriggerChainBuildModel{AppSlug: currentAppSlug, WorkflowID: currentWorkflowID})
+	}
+	return TriggerChainModel{
+		Depth:  chain.Depth + 1,
+		Builds: builds,
+	}
+}
+
+func (chain TriggerChainModel) contains(appSlug, workflowID string) bool {
+	for _, build := range chain.Builds {
+		if build.AppSlug == appSlug && build.WorkflowID == workflowID {
+			return true
+		}
+	}
+	return false
+}
+
+// String describes the chain for error messages, e.g. `primary (app) -> tests (app)`.
+func (chain TriggerChainModel) String() string {
+	builds := []string{}
+	for _, build := range chain.Builds {
+		builds = append(builds, fmt.Sprintf("%s (%s)", build.WorkflowID, build.AppSlug))
+	}
+	return strings.Join(builds, " -> ")
+}
+
+func (chain TriggerChainModel) toEnvironment() (EnvironmentVariableModel, error) {
+	value, err := json.Marshal(chain)
+	if err != nil {
+		return EnvironmentVariableModel{}, err
+	}
+	return EnvironmentVariableModel{
+		MappedTo: triggerChainEnvKey,
+		Value:    string(value),
+	}, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsRecursiveTrigger(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WorkflowID:        "primary",
+		TriggerChain:      `{"depth":1,"builds":[{"app_slug":"slug","workflow_id":"primary"}]}`,
+		CurrentWorkflowID: "tests",
+	}
+	require.EqualError(t, configs.validate(), "recursive trigger of workflow primary refused, trigger chain: primary (slug) -> tests (slug)")
+}
+
+func TestValidateConfigsCurrentWorkflowTrigger(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WorkflowID:        "tests",
+		CurrentWorkflowID: "tests",
+	}
+	require.EqualError(t, configs.validate(), "recursive trigger of workflow tests refused, trigger chain: tests (slug)")
+}
+
+func TestValidateConfigsMaxTriggerDepthExceeded(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WorkflowID:        "deploy",
+		MaxTriggerDepth:   "2",
+		TriggerChain:      `{"depth":2,"builds":[{"app_slug":"slug","workflow_id":"primary"},{"app_slug":"slug","workflow_id":"tests"}]}`,
+		CurrentWorkflowID: "ui-tests",
+	}
+	require.EqualError(t, configs.validate(), "trigger depth 3 exceeds the max trigger depth 2, trigger chain: primary (slug) -> tests (slug) -> ui-tests (slug)")
+
+	configs.MaxTriggerDepth = "3"
+	require.NoError(t, configs.validate())
+}
+
+func TestCreateRequestBodyFromConfigsInjectsTriggerChain(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		TriggerChain:      `{"depth":1,"builds":[{"app_slug":"slug","workflow_id":"primary"}]}`,
+		CurrentWorkflowID: "tests",
+		CurrentAppSlug:    "app",
+	}
+	body, err := createRequestBodyFromConfigs(configs, TriggerTargetModel{WorkflowID: "lint"})
+	require.NoError(t, err)
+
+	var requestModel RequestModel
+	require.NoError(t, json.Unmarshal(body, &requestModel))
+	require.Equal(t, 1, len(requestModel.BuildParams.Environments))
+	require.Equal(t, triggerChainEnvKey, requestModel.BuildParams.Environments[0].MappedTo)
+
+	chain, err := parseTriggerChain(requestModel.BuildParams.Environments[0].Value)
+	require.NoError(t, err)
+	require.Equal(t, 2, chain.Depth)
+	require.Equal(t, []TriggerChainBuildModel{{AppSlug: "slug", WorkflowID: "primary"}, {AppSlug: "app", WorkflowID: "tests"}}, chain.Builds)
+}
+
+func TestValidateConfigsSameWorkflowOfOtherApp(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "other",
+		WorkflowID:        "tests",
+		CurrentAppSlug:    "slug",
+		CurrentWorkflowID: "tests",
+	}
+	require.NoError(t, configs.validate())
+
+	configs.AppSlug = "slug"
+	require.EqualError(t, configs.validate(), "recursive trigger of workflow tests refused, trigger chain: tests (slug)")
+}
+
+func TestValidateTriggerTargetsRecursion(t *testing.T) {
+	configs := ConfigsModel{
+		AppSlug:           "slug",
+		CurrentAppSlug:    "slug",
+		CurrentWorkflowID: "tests",
+		AdditionalApps:    "other:OTHER_TOKEN:tests",
+	}
+
+	// e.g. the workflow locked in from the trigger map or selected by the path rules
+	configs.WorkflowID = "tests|lint"
+	targets, err := configs.triggerTargets()
+	require.NoError(t, err)
+	err = configs.validateTriggerTargets(targets)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "workflow tests of app slug")
+
+	configs.WorkflowID = "lint"
+	targets, err = configs.triggerTargets()
+	require.NoError(t, err)
+	require.NoError(t, configs.validateTriggerTargets(targets))
+
+	configs.CurrentAppSlug = "other"
+	err = configs.validateTriggerTargets(targets)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "workflow tests of app other")
+}
@@ -3,9 +3,12 @@ package main
 import (
 	"errors"
 	"fmt"
-	"github.com/bitrise-io/go-utils/log"
+	"net/url"
 	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 )
 
 func createConfigsModelFromEnvs() ConfigsModel {
@@ -17,6 +20,7 @@ func createConfigsModelFromEnvs() ConfigsModel {
 		CommitHash:               os.Getenv("commit_hash"),
 		CommitMessage:            os.Getenv("commit_message"),
 		WorkflowID:               os.Getenv("workflow_id"),
+		PipelineID:               os.Getenv("pipeline_id"),
 		BranchDest:               os.Getenv("branch_dest"),
 		PullRequestID:            os.Getenv("pull_request_id"),
 		PullRequestRepositoryURL: os.Getenv("pull_request_repository_url"),
@@ -25,27 +29,127 @@ func createConfigsModelFromEnvs() ConfigsModel {
 		ExportedVariableNames:    os.Getenv("exported_environment_variable_names"),
 		BranchRepoOwner:          os.Getenv("branch_repo_owner"),
 		BranchDestRepoOwner:      os.Getenv("branch_dest_repo_owner"),
+		WaitForBuild:             os.Getenv("wait_for_build"),
+		AccessToken:              os.Getenv("access_token"),
+		PollInterval:             os.Getenv("poll_interval"),
+		RetryCount:               os.Getenv("retry_count"),
+		APIBaseURL:               os.Getenv("api_base_url"),
+		APIProtocol:              os.Getenv("api_protocol"),
+		AbortReason:              os.Getenv("abort_reason"),
+		DownloadArtifacts:        os.Getenv("download_artifacts"),
+		ArtifactPatterns:         os.Getenv("artifact_patterns"),
+		ArtifactsDir:             os.Getenv("artifacts_dir"),
+		BuildLogMode:             os.Getenv("build_log_mode"),
+		BuildLogLines:            os.Getenv("build_log_lines"),
+		ResultPolicy:             os.Getenv("result_policy"),
+		FailFast:                 os.Getenv("fail_fast"),
+		MaxTriggerDepth:          os.Getenv("max_trigger_depth"),
+		TriggerChain:             os.Getenv(triggerChainEnvKey),
+		CurrentWorkflowID:        os.Getenv("BITRISE_TRIGGERED_WORKFLOW_ID"),
+		CurrentAppSlug:           os.Getenv("BITRISE_APP_SLUG"),
+		SkipIfRunning:            os.Getenv("skip_if_running"),
+		CancelSupersededBuilds:   os.Getenv("cancel_superseded_builds"),
+		SupersededSkipTags:       os.Getenv("superseded_skip_tags"),
+		SupersededSkipPRs:        os.Getenv("superseded_skip_pull_requests"),
+		AdditionalApps:           os.Getenv("additional_apps"),
+		Matrix:                   os.Getenv("matrix"),
+		MatrixInclude:            os.Getenv("matrix_include"),
+		MatrixExclude:            os.Getenv("matrix_exclude"),
+		PathRules:                os.Getenv("path_rules"),
+		ChangesBaseRef:           os.Getenv("changes_base_ref"),
+		BitriseYMLPath:           os.Getenv("bitrise_yml_path"),
+		TriggerMapResolution:     os.Getenv("trigger_map_resolution"),
+		ValidateWorkflows:        os.Getenv("validate_workflows"),
+		LogFormat:                os.Getenv("log_format"),
+		ConnectTimeout:           os.Getenv("connect_timeout"),
+		ResponseTimeout:          os.Getenv("response_timeout"),
+		CABundlePath:             os.Getenv("ca_bundle_path"),
+		WaitForAllResults:        os.Getenv("wait_for_all_results"),
+
+		SkipGitStatusReport:              os.Getenv("skip_git_status_report"),
+		CommitPaths:                      os.Getenv("commit_paths"),
+		DiffURL:                          os.Getenv("diff_url"),
+		BaseRepositoryURL:                os.Getenv("base_repository_url"),
+		HeadRepositoryURL:                os.Getenv("head_repository_url"),
+		PullRequestAuthor:                os.Getenv("pull_request_author"),
+		PullRequestUnverifiedMergeBranch: os.Getenv("pull_request_unverified_merge_branch"),
+		StackID:                          os.Getenv("stack_id"),
+		MachineTypeID:                    os.Getenv("machine_type_id"),
+		Priority:                         os.Getenv("priority"),
 	}
 }
 
 func (configs ConfigsModel) dump() {
-	fmt.Println()
-	log.Infof("Configs:")
-	log.Printf(" - AppSlug (hidden): %s", configs.AppSlug)
-	log.Printf(" - ApiToken (hidden): %s", strings.Repeat("*", 5))
-	log.Printf(" - Branch: %s", configs.Branch)
-	log.Printf(" - Tag: %s", configs.Tag)
-	log.Printf(" - CommitHash: %s", configs.CommitHash)
-	log.Printf(" - CommitMessage: %s", configs.CommitMessage)
-	log.Printf(" - WorkflowID: %s", configs.WorkflowID)
-	log.Printf(" - BranchDest: %s", configs.BranchDest)
-	log.Printf(" - PullRequestID: %s", configs.PullRequestID)
-	log.Printf(" - PullRequestRepositoryURL: %s", configs.PullRequestRepositoryURL)
-	log.Printf(" - PullRequestMergeBranch: %s", configs.PullRequestMergeBranch)
-	log.Printf(" - PullRequestHeadBranch: %s", configs.PullRequestHeadBranch)
-	log.Printf(" - ExportedVariableNames: %s", configs.ExportedVariableNames)
-	log.Printf(" - BranchRepoOwner: %s", configs.BranchRepoOwner)
-	log.Printf(" - BranchDestRepoOwner: %s", configs.BranchDestRepoOwner)
+	logger.Section()
+	logger.Dump("config", "Configs:", configs.dumpFields())
+}
+
+// dumpFields returns the configs to print, with the secrets hidden.
+func (configs ConfigsModel) dumpFields() []dumpField {
+	return []dumpField{
+		{"AppSlug (hidden)", "app_slug", configs.AppSlug},
+		{"ApiToken (hidden)", "api_token", strings.Repeat("*", 5)},
+		{"Branch", "branch", configs.Branch},
+		{"Tag", "tag", configs.Tag},
+		{"CommitHash", "commit_hash", configs.CommitHash},
+		{"CommitMessage", "commit_message", configs.CommitMessage},
+		{"WorkflowID", "workflow_id", configs.WorkflowID},
+		{"PipelineID", "pipeline_id", configs.PipelineID},
+		{"BranchDest", "branch_dest", configs.BranchDest},
+		{"PullRequestID", "pull_request_id", configs.PullRequestID},
+		{"PullRequestRepositoryURL", "pull_request_repository_url", configs.PullRequestRepositoryURL},
+		{"PullRequestMergeBranch", "pull_request_merge_branch", configs.PullRequestMergeBranch},
+		{"PullRequestHeadBranch", "pull_request_head_branch", configs.PullRequestHeadBranch},
+		{"ExportedVariableNames", "exported_variable_names", configs.ExportedVariableNames},
+		{"BranchRepoOwner", "branch_repo_owner", configs.BranchRepoOwner},
+		{"BranchDestRepoOwner", "branch_dest_repo_owner", configs.BranchDestRepoOwner},
+		{"SkipGitStatusReport", "skip_git_status_report", configs.SkipGitStatusReport},
+		{"CommitPaths", "commit_paths", configs.CommitPaths},
+		{"DiffURL", "diff_url", configs.DiffURL},
+		{"BaseRepositoryURL", "base_repository_url", configs.BaseRepositoryURL},
+		{"HeadRepositoryURL", "head_repository_url", configs.HeadRepositoryURL},
+		{"PullRequestAuthor", "pull_request_author", configs.PullRequestAuthor},
+		{"PullRequestUnverifiedMergeBranch", "pull_request_unverified_merge_branch", configs.PullRequestUnverifiedMergeBranch},
+		{"StackID", "stack_id", configs.StackID},
+		{"MachineTypeID", "machine_type_id", configs.MachineTypeID},
+		{"Priority", "priority", configs.Priority},
+		{"WaitForBuild", "wait_for_build", configs.WaitForBuild},
+		{"WaitForAllResults", "wait_for_all_results", configs.WaitForAllResults},
+		{"AccessToken (hidden)", "access_token", strings.Repeat("*", 5)},
+		{"PollInterval", "poll_interval", configs.PollInterval},
+		{"AbortReason", "abort_reason", configs.AbortReason},
+		{"DownloadArtifacts", "download_artifacts", configs.DownloadArtifacts},
+		{"ArtifactPatterns", "artifact_patterns", configs.ArtifactPatterns},
+		{"ArtifactsDir", "artifacts_dir", configs.ArtifactsDir},
+		{"BuildLogMode", "build_log_mode", configs.BuildLogMode},
+		{"BuildLogLines", "build_log_lines", configs.BuildLogLines},
+		{"ResultPolicy", "result_policy", configs.ResultPolicy},
+		{"FailFast", "fail_fast", configs.FailFast},
+		{"MaxTriggerDepth", "max_trigger_depth", configs.MaxTriggerDepth},
+		{"TriggerChain", "trigger_chain", configs.TriggerChain},
+		{"CurrentWorkflowID", "current_workflow_id", configs.CurrentWorkflowID},
+		{"CurrentAppSlug", "current_app_slug", configs.CurrentAppSlug},
+		{"SkipIfRunning", "skip_if_running", configs.SkipIfRunning},
+		{"CancelSupersededBuilds", "cancel_superseded_builds", configs.CancelSupersededBuilds},
+		{"SupersededSkipTags", "superseded_skip_tags", configs.SupersededSkipTags},
+		{"SupersededSkipPRs", "superseded_skip_pull_requests", configs.SupersededSkipPRs},
+		{"AdditionalApps", "additional_apps", configs.AdditionalApps},
+		{"Matrix", "matrix", configs.Matrix},
+		{"MatrixInclude", "matrix_include", configs.MatrixInclude},
+		{"MatrixExclude", "matrix_exclude", configs.MatrixExclude},
+		{"PathRules", "path_rules", configs.PathRules},
+		{"ChangesBaseRef", "changes_base_ref", configs.ChangesBaseRef},
+		{"BitriseYMLPath", "bitrise_yml_path", configs.BitriseYMLPath},
+		{"TriggerMapResolution", "trigger_map_resolution", configs.TriggerMapResolution},
+		{"ValidateWorkflows", "validate_workflows", configs.ValidateWorkflows},
+		{"LogFormat", "log_format", configs.LogFormat},
+		{"RetryCount", "retry_count", configs.RetryCount},
+		{"ConnectTimeout", "connect_timeout", configs.ConnectTimeout},
+		{"ResponseTimeout", "response_timeout", configs.ResponseTimeout},
+		{"CABundlePath", "ca_bundle_path", configs.CABundlePath},
+		{"APIBaseURL", "api_base_url", configs.APIBaseURL},
+		{"APIProtocol", "api_protocol", configs.APIProtocol},
+	}
 }
 
 func (configs ConfigsModel) validate() error {
@@ -53,7 +157,19 @@ func (configs ConfigsModel) validate() error {
 		return errors.New("empty App slug specified")
 	}
 
-	if configs.APIToken == "" {
+	if configs.LogFormat != "" && configs.LogFormat != logFormatText && configs.LogFormat != logFormatJSON {
+		return fmt.Errorf("invalid log format specified: %s, allowed: %s, %s", configs.LogFormat, logFormatText, logFormatJSON)
+	}
+
+	if configs.APIProtocol != "" && configs.APIProtocol != apiProtocolLegacy && configs.APIProtocol != apiProtocolREST {
+		return fmt.Errorf("invalid API protocol specified: %s, allowed: %s, %s", configs.APIProtocol, apiProtocolLegacy, apiProtocolREST)
+	}
+
+	if configs.isRESTProtocol() {
+		if configs.AccessToken == "" {
+			return errors.New("empty Access token specified, it is required by the REST API protocol")
+		}
+	} else if configs.APIToken == "" {
 		return errors.New("empty Build Trigger API token specified")
 	}
 
@@ -65,5 +181,493 @@ func (configs ConfigsModel) validate() error {
 		}
 	}
 
+	workflowIDs := map[string]bool{}
+	for _, workflowID := range splitPipeSeparatedStringArray(configs.WorkflowID) {
+		if workflowID == "" {
+			return errors.New("empty workflow ID specified in the list")
+		} else if workflowIDs[workflowID] {
+			return fmt.Errorf("workflow ID specified more than once: %s", workflowID)
+		} else if isUtilityWorkflow(workflowID) {
+			return fmt.Errorf("utility workflow specified: %s, workflows starting with _ cannot be triggered", workflowID)
+		}
+		workflowIDs[workflowID] = true
+	}
+
+	if configs.PipelineID != "" {
+		if err := configs.validatePipeline(); err != nil {
+			return err
+		}
+	}
+
+	if err := configs.validateBuildParams(); err != nil {
+		return err
+	}
+
+	apps, err := parseAdditionalApps(configs.AdditionalApps)
+	if err != nil {
+		return err
+	}
+
+	for _, app := range apps {
+		if configs.isRESTProtocol() {
+			continue
+		}
+
+		if app.TokenEnvVar == "" {
+			return fmt.Errorf("empty token environment variable specified for app: %s", app.AppSlug)
+		} else if os.Getenv(app.TokenEnvVar) == "" {
+			return fmt.Errorf("empty Build Trigger API token in environment variable %s for app: %s", app.TokenEnvVar, app.AppSlug)
+		}
+	}
+
+	if _, err := expandMatrix(configs.Matrix, configs.MatrixInclude, configs.MatrixExclude); err != nil {
+		return err
+	}
+
+	switch configs.TriggerMapResolution {
+	case "", triggerMapResolutionNone:
+	case triggerMapResolutionShow, triggerMapResolutionLock:
+		if configs.BitriseYMLPath == "" {
+			return errors.New("no bitrise.yml path specified, it is required by the trigger map resolution")
+		}
+		if configs.WorkflowID != "" || configs.PipelineID != "" {
+			return errors.New("workflow or pipeline ID specified, the trigger map can only be resolved if both of them are empty")
+		}
+	default:
+		return fmt.Errorf("invalid trigger map resolution specified: %s, allowed: %s, %s, %s", configs.TriggerMapResolution, triggerMapResolutionNone, triggerMapResolutionShow, triggerMapResolutionLock)
+	}
+
+	rules, err := parsePathRules(configs.PathRules)
+	if err != nil {
+		return err
+	}
+
+	if len(rules) > 0 && configs.changesBaseRef() == "" {
+		return errors.New("empty changes base ref and Pull Request destination branch specified, one of them is required by the path rules")
+	}
+
+	for _, rule := range rules {
+		for _, workflowID := range rule.WorkflowIDs {
+			if isUtilityWorkflow(workflowID) {
+				return fmt.Errorf("utility workflow specified in path rule %s: %s, workflows starting with _ cannot be triggered", rule.Pattern, workflowID)
+			}
+		}
+	}
+
+	outputWorkflowIDs := splitPipeSeparatedStringArray(configs.WorkflowID)
+	for _, rule := range rules {
+		outputWorkflowIDs = append(outputWorkflowIDs, rule.WorkflowIDs...)
+	}
+	outputAppSlugs := []string{configs.AppSlug}
+	for _, app := range apps {
+		outputWorkflowIDs = append(outputWorkflowIDs, app.WorkflowIDs...)
+		outputAppSlugs = append(outputAppSlugs, app.AppSlug)
+	}
+
+	if err := validateOutputKeySuffixes("workflow IDs", outputWorkflowIDs); err != nil {
+		return err
+	}
+	if err := validateOutputKeySuffixes("app slugs", outputAppSlugs); err != nil {
+		return err
+	}
+
+	if err := configs.validateTriggerChain(); err != nil {
+		return err
+	}
+
+	if configs.APIBaseURL != "" {
+		baseURL, err := url.Parse(configs.APIBaseURL)
+		if err != nil {
+			return fmt.Errorf("invalid API base URL specified: %s", err)
+		} else if (baseURL.Scheme != "http" && baseURL.Scheme != "https") || baseURL.Host == "" {
+			return fmt.Errorf("invalid API base URL specified: %s, must be an absolute http or https URL", configs.APIBaseURL)
+		}
+	}
+
+	if configs.RetryCount != "" {
+		if retryCount, err := strconv.Atoi(configs.RetryCount); err != nil || retryCount < 0 {
+			return fmt.Errorf("invalid retry count specified: %s, must be a non-negative integer", configs.RetryCount)
+		}
+	}
+
+	if configs.ConnectTimeout != "" {
+		if _, err := parsePositiveInt(configs.ConnectTimeout); err != nil {
+			return fmt.Errorf("invalid connect timeout specified: %s", err)
+		}
+	}
+
+	if configs.ResponseTimeout != "" {
+		if _, err := parsePositiveInt(configs.ResponseTimeout); err != nil {
+			return fmt.Errorf("invalid response timeout specified: %s", err)
+		}
+	}
+
+	if configs.CABundlePath != "" {
+		if _, err := loadCABundle(configs.CABundlePath); err != nil {
+			return err
+		}
+	}
+
+	if err := validateYesNo("skip if running", configs.SkipIfRunning); err != nil {
+		return err
+	}
+
+	if configs.isSkipIfRunning() && configs.AccessToken == "" {
+		return errors.New("empty Access token specified, it is required to check the running builds")
+	}
+
+	for name, value := range map[string]string{
+		"cancel superseded builds":      configs.CancelSupersededBuilds,
+		"superseded skip tags":          configs.SupersededSkipTags,
+		"superseded skip pull requests": configs.SupersededSkipPRs,
+	} {
+		if err := validateYesNo(name, value); err != nil {
+			return err
+		}
+	}
+
+	if configs.isCancelSupersededBuilds() && configs.AccessToken == "" {
+		return errors.New("empty Access token specified, it is required to cancel superseded builds")
+	}
+
+	if err := validateYesNo("wait for build", configs.WaitForBuild); err != nil {
+		return err
+	}
+
+	if err := validateYesNo("wait for all results", configs.WaitForAllResults); err != nil {
+		return err
+	}
+
+	if err := validateYesNo("download artifacts", configs.DownloadArtifacts); err != nil {
+		return err
+	}
+
+	if configs.isWaitForBuild() {
+		if configs.AccessToken == "" {
+			return errors.New("empty Access token specified, it is required when waiting for the triggered build")
+		}
+
+		if _, err := parsePositiveInt(configs.PollInterval); err != nil {
+			return fmt.Errorf("invalid poll interval specified: %s", err)
+		}
+	}
+
+	policy, err := parseResultPolicy(configs.ResultPolicy)
+	if err != nil {
+		return err
+	}
+
+	if err := validateYesNo("fail fast", configs.FailFast); err != nil {
+		return err
+	}
+
+	if configs.isFailFast() && policy.name != resultPolicyAll {
+		return fmt.Errorf("fail fast can be used only with the %s result policy", resultPolicyAll)
+	}
+
+	if configs.isFailFast() && !configs.isWaitForBuild() {
+		return errors.New("fail fast can be used only when waiting for the triggered builds")
+	}
+
+	// With path rules the targets are known only once the changed files are checked, see validateTriggerTargets.
+	if configs.PathRules == "" {
+		targets, err := configs.triggerTargets()
+		if err != nil {
+			return err
+		}
+		if err := configs.validateResultPolicyTargets(policy, targets); err != nil {
+			return err
+		}
+	}
+
+	switch configs.BuildLogMode {
+	case "", buildLogModeAlways, buildLogModeOnFailure, buildLogModeNever:
+	default:
+		return fmt.Errorf("invalid build log mode specified: %s, allowed: %s, %s, %s", configs.BuildLogMode, buildLogModeAlways, buildLogModeOnFailure, buildLogModeNever)
+	}
+
+	if configs.BuildLogMode == buildLogModeOnFailure && configs.BuildLogLines != "" {
+		if _, err := parsePositiveInt(configs.BuildLogLines); err != nil {
+			return fmt.Errorf("invalid build log lines specified: %s", err)
+		}
+	}
+
+	if err := validateYesNo("validate workflows", configs.ValidateWorkflows); err != nil {
+		return err
+	}
+
+	if configs.isValidateWorkflows() {
+		if configs.BitriseYMLPath == "" && configs.AccessToken == "" {
+			return errors.New("no bitrise.yml path or Access token specified, one of them is required to validate the workflows")
+		}
+	}
+
+	if configs.isDownloadArtifacts() {
+		if !configs.isWaitForBuild() {
+			return errors.New("artifacts can be downloaded only when waiting for the triggered build")
+		}
+
+		if configs.ArtifactsDir == "" {
+			return errors.New("empty artifacts directory specified")
+		}
+
+		for _, pattern := range configs.artifactPatterns() {
+			if _, err := filepath.Match(pattern, ""); err != nil {
+				return fmt.Errorf("invalid artifact pattern specified: %s", pattern)
+			}
+		}
+	}
+
+	return nil
+}
+
+// validatePipeline checks that the pipeline ID is not combined with inputs which select or handle workflows only.
+func (configs ConfigsModel) validatePipeline() error {
+	if configs.WorkflowID != "" {
+		return errors.New("both workflow ID and pipeline ID specified, only one of them can be triggered")
+	} else if strings.Contains(configs.PipelineID, "|") {
+		return fmt.Errorf("multiple pipeline IDs specified: %s, only one pipeline can be triggered", configs.PipelineID)
+	}
+
+	for name, value := range map[string]string{
+		"additional apps":    configs.AdditionalApps,
+		"matrix":             configs.Matrix,
+		"path rules":         configs.PathRules,
+		"download artifacts": configs.DownloadArtifacts,
+	} {
+		if value != "" && value != "no" {
+			return fmt.Errorf("%s specified, it can only be used when triggering workflows", name)
+		}
+	}
 	return nil
 }
+
+// validateTriggerChain refuses to trigger builds deeper than the maximum trigger depth
+// or workflows which already take part in the chain of builds triggered by this step.
+func (configs ConfigsModel) validateTriggerChain() error {
+	maxDepth := defaultMaxTriggerDepth
+	if configs.MaxTriggerDepth != "" {
+		var err error
+		if maxDepth, err = parsePositiveInt(configs.MaxTriggerDepth); err != nil {
+			return fmt.Errorf("invalid max trigger depth specified: %s", err)
+		}
+	}
+
+	chain, err := configs.triggerChain()
+	if err != nil {
+		return err
+	}
+
+	if chain.Depth > maxDepth {
+		return fmt.Errorf("trigger depth %d exceeds the max trigger depth %d, trigger chain: %s", chain.Depth, maxDepth, chain)
+	}
+
+	for _, workflowID := range splitPipeSeparatedStringArray(configs.WorkflowID) {
+		if chain.contains(configs.AppSlug, workflowID) {
+			return fmt.Errorf("recursive trigger of workflow %s refused, trigger chain: %s", workflowID, chain)
+		}
+	}
+
+	return nil
+}
+
+// validateTriggerTargets refuses targets whose workflow already takes part in the chain of builds triggered by this step.
+// Unlike validate, it checks the final targets, after the workflows are selected by the trigger map, the path rules and the additional apps.
+func (configs ConfigsModel) validateTriggerTargets(targets []TriggerTargetModel) error {
+	chain, err := configs.triggerChain()
+	if err != nil {
+		return err
+	}
+
+	for _, target := range targets {
+		if target.WorkflowID != "" && chain.contains(target.AppSlug, target.WorkflowID) {
+			return fmt.Errorf("recursive trigger of workflow %s of app %s refused, trigger chain: %s", target.WorkflowID, target.AppSlug, chain)
+		}
+	}
+
+	policy, err := parseResultPolicy(configs.ResultPolicy)
+	if err != nil {
+		return err
+	}
+	return configs.validateResultPolicyTargets(policy, targets)
+}
+
+// validateResultPolicyTargets refuses an `at-least:N` result policy which cannot be satisfied by the builds of the targets.
+// If every result of the trigger requests is waited for, a target may start more than one build, so the check is skipped.
+func (configs ConfigsModel) validateResultPolicyTargets(policy resultPolicy, targets []TriggerTargetModel) error {
+	if !configs.isWaitForBuild() || configs.isWaitForAllResults() || !strings.HasPrefix(policy.name, resultPolicyAtLeast) {
+		return nil
+	}
+
+	if policy.minSuccessful > len(targets) {
+		return fmt.Errorf("result policy %s cannot be satisfied, only %d build(s) are triggered", policy.name, len(targets))
+	}
+	return nil
+}
+
+// triggerChain returns the chain of the builds triggered by the current build.
+func (configs ConfigsModel) triggerChain() (TriggerChainModel, error) {
+	chain, err := parseTriggerChain(configs.TriggerChain)
+	if err != nil {
+		return chain, err
+	}
+	return chain.next(configs.currentAppSlug(), configs.CurrentWorkflowID), nil
+}
+
+// secretValues returns the tokens of the inputs, including the ones of the additional apps read from the environment.
+func (configs ConfigsModel) secretValues() []string {
+	secrets := []string{configs.APIToken, configs.AccessToken}
+
+	apps, err := parseAdditionalApps(configs.AdditionalApps)
+	if err != nil {
+		return secrets
+	}
+	for _, app := range apps {
+		if app.TokenEnvVar != "" {
+			secrets = append(secrets, os.Getenv(app.TokenEnvVar))
+		}
+	}
+	return secrets
+}
+
+// currentAppSlug returns the app of the current build. If it is unknown (e.g. when running locally),
+// the target app is assumed, so the recursion check errs on the safe side.
+func (configs ConfigsModel) currentAppSlug() string {
+	if configs.CurrentAppSlug != "" {
+		return configs.CurrentAppSlug
+	}
+	return configs.AppSlug
+}
+
+// validateOutputKeySuffixes refuses distinct identifiers which would export their outputs under the same key,
+// e.g. ui-tests and ui_tests.
+func validateOutputKeySuffixes(kind string, identifiers []string) error {
+	identifiersBySuffix := map[string]string{}
+	for _, identifier := range identifiers {
+		suffix := outputKeySuffix(identifier)
+		if other, ok := identifiersBySuffix[suffix]; ok && other != identifier {
+			return fmt.Errorf("%s %s and %s would export their outputs under the same key suffix: %s", kind, other, identifier, suffix)
+		}
+		identifiersBySuffix[suffix] = identifier
+	}
+	return nil
+}
+
+func validateYesNo(name, value string) error {
+	if value != "yes" && value != "no" && value != "" {
+		return fmt.Errorf("invalid %s value specified: %s, allowed: yes, no", name, value)
+	}
+	return nil
+}
+
+// workflowIDs returns the workflows to trigger. A single empty ID means the workflow is selected by the Trigger Map.
+func (configs ConfigsModel) workflowIDs() []string {
+	workflowIDs := splitPipeSeparatedStringArray(configs.WorkflowID)
+	if len(workflowIDs) == 0 {
+		return []string{""}
+	}
+	return workflowIDs
+}
+
+// changesBaseRef returns the git ref the changed files are computed against.
+func (configs ConfigsModel) changesBaseRef() string {
+	if configs.ChangesBaseRef != "" {
+		return configs.ChangesBaseRef
+	}
+	if configs.BranchDest != "" {
+		return "origin/" + configs.BranchDest
+	}
+	return ""
+}
+
+func (configs ConfigsModel) isSkipGitStatusReport() bool {
+	return configs.SkipGitStatusReport == "yes"
+}
+
+func (configs ConfigsModel) isValidateWorkflows() bool {
+	return configs.ValidateWorkflows == "yes"
+}
+
+func (configs ConfigsModel) isRESTProtocol() bool {
+	return configs.APIProtocol == apiProtocolREST
+}
+
+func (configs ConfigsModel) isSkipIfRunning() bool {
+	return configs.SkipIfRunning == "yes"
+}
+
+func (configs ConfigsModel) isCancelSupersededBuilds() bool {
+	return configs.CancelSupersededBuilds == "yes"
+}
+
+func (configs ConfigsModel) isSupersededSkipTags() bool {
+	return configs.SupersededSkipTags == "yes"
+}
+
+func (configs ConfigsModel) isSupersededSkipPullRequests() bool {
+	return configs.SupersededSkipPRs == "yes"
+}
+
+func (configs ConfigsModel) isFailFast() bool {
+	return configs.FailFast == "yes"
+}
+
+func (configs ConfigsModel) isDownloadArtifacts() bool {
+	return configs.DownloadArtifacts == "yes"
+}
+
+func (configs ConfigsModel) artifactPatterns() []string {
+	patterns := splitPipeSeparatedStringArray(configs.ArtifactPatterns)
+	if len(patterns) == 0 {
+		return []string{"*"}
+	}
+	return patterns
+}
+
+func (configs ConfigsModel) buildLogLines() int {
+	lines, err := parsePositiveInt(configs.BuildLogLines)
+	if err != nil {
+		return defaultBuildLogLines
+	}
+	return lines
+}
+
+func (configs ConfigsModel) retryCount() int {
+	retryCount, err := strconv.Atoi(configs.RetryCount)
+	if err != nil || retryCount < 0 {
+		return defaultRetryCount
+	}
+	return retryCount
+}
+
+func (configs ConfigsModel) isWaitForAllResults() bool {
+	return configs.WaitForAllResults == "yes"
+}
+
+func (configs ConfigsModel) isWaitForBuild() bool {
+	return configs.WaitForBuild == "yes"
+}
+
+func (configs ConfigsModel) connectTimeout() time.Duration {
+	seconds, err := parsePositiveInt(configs.ConnectTimeout)
+	if err != nil {
+		return defaultConnectTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (configs ConfigsModel) responseTimeout() time.Duration {
+	seconds, err := parsePositiveInt(configs.ResponseTimeout)
+	if err != nil {
+		return defaultResponseTimeout
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+func (configs ConfigsModel) pollInterval() time.Duration {
+	seconds, err := parsePositiveInt(configs.PollInterval)
+	if err != nil {
+		return defaultPollInterval
+	}
+	return time.Duration(seconds) * time.Second
+}
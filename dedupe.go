@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+)
+
+// findRunningBuild returns a running or on hold build of the app with the same workflow, branch and commit hash, if any.
+func findRunningBuild(client apiClient, appSlug, workflowID, branch, commitHash string) (BuildModel, bool, error) {
+	query := url.Values{}
+	query.Set("workflow", workflowID)
+	query.Set("branch", branch)
+	query.Set("status", "0")
+
+	builds, err := listBuilds(client, appSlug, query)
+	if err != nil {
+		return BuildModel{}, false, err
+	}
+
+	for _, build := range builds {
+		if build.Status == 0 && build.TriggeredWorkflow == workflowID && build.Branch == branch && build.CommitHash == commitHash {
+			return build, true, nil
+		}
+	}
+	return BuildModel{}, false, nil
+}
+
+// listBuilds returns every build of the app matching the query, following the pagination.
+func listBuilds(client apiClient, appSlug string, query url.Values) ([]BuildModel, error) {
+	builds := []BuildModel{}
+
+	for {
+		var responseModel BuildListResponseModel
+		path := fmt.Sprintf("/v0.1/apps/%s/builds?%s", appSlug, query.Encode())
+		if err := client.sendRESTRequest(context.Background(), "GET", path, nil, &responseModel); err != nil {
+			return builds, err
+		}
+		builds = append(builds, responseModel.Data...)
+
+		if responseModel.Paging.Next == "" {
+			return builds, nil
+		}
+		query.Set("next", responseModel.Paging.Next)
+		logger.Debugf("Fetching next page of builds: %s", responseModel.Paging.Next)
+	}
+}
+
+// cancelAllSupersededBuilds aborts the builds superseded by the newest triggered build of each workflow.
+// Builds triggered by the step itself (e.g. other matrix cells of the same workflow) are never aborted.
+func cancelAllSupersededBuilds(client apiClient, configs ConfigsModel, builds []TriggeredBuildModel) {
+	newestBuilds := []TriggeredBuildModel{}
+	newestIndex := map[string]int{}
+	for _, build := range builds {
+		if build.WorkflowID == "" || build.Reused {
+			continue
+		}
+
+		key := build.AppSlug + "/" + build.WorkflowID
+		if i, ok := newestIndex[key]; !ok {
+			newestIndex[key] = len(newestBuilds)
+			newestBuilds = append(newestBuilds, build)
+		} else if build.BuildNumber > newestBuilds[i].BuildNumber {
+			newestBuilds[i] = build
+		}
+	}
+
+	for _, build := range newestBuilds {
+		if err := cancelSupersededBuilds(client, build, builds, configs.Branch, configs.isSupersededSkipTags(), configs.isSupersededSkipPullRequests()); err != nil {
+			logger.Warnf("Could not cancel superseded builds of workflow %s, error: %s", build.WorkflowID, err)
+		}
+	}
+}
+
+// cancelSupersededBuilds aborts the running builds of the app with the same workflow and branch
+// which were started before the given build, except the triggered builds.
+func cancelSupersededBuilds(client apiClient, build TriggeredBuildModel, triggeredBuilds []TriggeredBuildModel, branch string, skipTags, skipPullRequests bool) error {
+	query := url.Values{}
+	query.Set("workflow", build.WorkflowID)
+	query.Set("branch", branch)
+	query.Set("status", "0")
+
+	runningBuilds, err := listBuilds(client, build.AppSlug, query)
+	if err != nil {
+		return err
+	}
+
+	reason := fmt.Sprintf("Superseded by build #%d", build.BuildNumber)
+	for _, runningBuild := range supersededBuilds(runningBuilds, build, branch, skipTags, skipPullRequests) {
+		if isTriggeredBuild(triggeredBuilds, build.AppSlug, runningBuild.Slug) {
+			continue
+		}
+
+		logger.Warnf("Aborting superseded build #%d (%s)", runningBuild.BuildNumber, runningBuild.Slug)
+		if err := abortBuild(client, build.AppSlug, runningBuild.Slug, reason); err != nil {
+			logger.Errorf("Could not abort build %s, error: %s", runningBuild.Slug, err)
+		}
+	}
+	return nil
+}
+
+func supersededBuilds(runningBuilds []BuildModel, build TriggeredBuildModel, branch string, skipTags, skipPullRequests bool) []BuildModel {
+	superseded := []BuildModel{}
+	for _, runningBuild := range runningBuilds {
+		switch {
+		case runningBuild.Status != 0 || runningBuild.Slug == build.BuildSlug || runningBuild.BuildNumber >= build.BuildNumber:
+		case runningBuild.TriggeredWorkflow != build.WorkflowID || runningBuild.Branch != branch:
+		case skipTags && runningBuild.Tag != "":
+		case skipPullRequests && runningBuild.PullRequestID != 0:
+		default:
+			superseded = append(superseded, runningBuild)
+		}
+	}
+	return superseded
+}
+
+func isTriggeredBuild(builds []TriggeredBuildModel, appSlug, buildSlug string) bool {
+	for _, build := range builds {
+		if build.AppSlug == appSlug && build.BuildSlug == buildSlug {
+			return true
+		}
+	}
+	return false
+}
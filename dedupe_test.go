@@ -0,0 +1,99 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsSkipIfRunningWithoutAccessToken(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:      "token",
+		AppSlug:       "slug",
+		SkipIfRunning: "yes",
+	}
+	require.EqualError(t, configs.validate(), "empty Access token specified, it is required to check the running builds")
+}
+
+func TestFindRunningBuild(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v0.1/apps/app/builds", r.URL.Path)
+		require.Equal(t, "lint", r.URL.Query().Get("workflow"))
+		require.Equal(t, "master", r.URL.Query().Get("branch"))
+
+		_, err := w.Write([]byte(`{"data":[
+			{"slug":"other-commit","status":0,"triggered_workflow":"lint","branch":"master","commit_hash":"abc"},
+			{"slug":"same-commit","status":0,"build_number":7,"triggered_workflow":"lint","branch":"master","commit_hash":"def","is_on_hold":true}
+		],"paging":{}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access"})
+	require.NoError(t, err)
+
+	build, found, err := findRunningBuild(client, "app", "lint", "master", "def")
+	require.NoError(t, err)
+	require.True(t, found)
+	require.Equal(t, "same-commit", build.Slug)
+
+	_, found, err = findRunningBuild(client, "app", "lint", "master", "123")
+	require.NoError(t, err)
+	require.False(t, found)
+}
+
+func TestSupersededBuilds(t *testing.T) {
+	build := TriggeredBuildModel{WorkflowID: "lint", BuildSlug: "new", BuildNumber: 10}
+	runningBuilds := []BuildModel{
+		{Slug: "old", BuildNumber: 8, TriggeredWorkflow: "lint", Branch: "feature"},
+		{Slug: "new", BuildNumber: 10, TriggeredWorkflow: "lint", Branch: "feature"},
+		{Slug: "newer", BuildNumber: 11, TriggeredWorkflow: "lint", Branch: "feature"},
+		{Slug: "other-workflow", BuildNumber: 7, TriggeredWorkflow: "tests", Branch: "feature"},
+		{Slug: "tag", BuildNumber: 6, TriggeredWorkflow: "lint", Branch: "feature", Tag: "1.0.0"},
+		{Slug: "pull-request", BuildNumber: 5, TriggeredWorkflow: "lint", Branch: "feature", PullRequestID: 3},
+	}
+
+	slugs := func(builds []BuildModel) []string {
+		result := []string{}
+		for _, build := range builds {
+			result = append(result, build.Slug)
+		}
+		return result
+	}
+
+	require.Equal(t, []string{"old", "tag", "pull-request"}, slugs(supersededBuilds(runningBuilds, build, "feature", false, false)))
+	require.Equal(t, []string{"old"}, slugs(supersededBuilds(runningBuilds, build, "feature", true, true)))
+}
+
+func TestCancelAllSupersededBuildsSkipsTriggeredBuilds(t *testing.T) {
+	abortedPaths := []string{}
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "POST" {
+			mutex.Lock()
+			abortedPaths = append(abortedPaths, r.URL.Path)
+			mutex.Unlock()
+			return
+		}
+		_, err := w.Write([]byte(`{"data":[
+			{"slug":"old","build_number":1,"triggered_workflow":"lint","branch":"feature"},
+			{"slug":"cell-0","build_number":2,"triggered_workflow":"lint","branch":"feature"},
+			{"slug":"cell-1","build_number":3,"triggered_workflow":"lint","branch":"feature"}
+		],"paging":{}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", Branch: "feature", RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	cancelAllSupersededBuilds(client, configs, []TriggeredBuildModel{
+		{AppSlug: "app", WorkflowID: "lint", BuildSlug: "cell-0", BuildNumber: 2},
+		{AppSlug: "app", WorkflowID: "lint", BuildSlug: "cell-1", BuildNumber: 3},
+	})
+	require.Equal(t, []string{"/v0.1/apps/app/builds/old/abort"}, abortedPaths)
+}
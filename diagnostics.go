@@ -0,0 +1,140 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+const maxResponseExcerptLength = 200
+
+// APIErrorResponseModel is the error body of the Bitrise APIs. The build trigger API uses the message field,
+// the REST API either the message or the error_msg field.
+type APIErrorResponseModel struct {
+	Status       string `json:"status"`
+	Message      string `json:"message"`
+	ErrorMessage string `json:"error_msg"`
+}
+
+var secretFieldPattern = regexp.MustCompile(`(?i)("?(?:api_token|access_token|token|authorization|password|secret)"?\s*[:=]\s*"?)[^"\s,&}]+`)
+
+// newResponseError describes an unsuccessful response: the error message of the API if the body contains one,
+// guidance on the likely cause and otherwise a redacted excerpt of the body.
+func newResponseError(request *http.Request, response *http.Response, body []byte, secrets []string) error {
+	description := fmt.Sprintf("%s %s failed, status: %s", request.Method, request.URL.Path, response.Status)
+	if message := apiErrorMessage(response, body); message != "" {
+		description += ", message: " + redactSecrets(message, request, secrets)
+	} else if excerpt := responseExcerpt(body, request, secrets); excerpt != "" {
+		description += ", response: " + excerpt
+	}
+
+	if guidance := responseGuidance(response.StatusCode); guidance != "" {
+		description += "\n" + guidance
+	}
+	return &stepError{category: responseErrorCategory(response.StatusCode), err: fmt.Errorf("%s", description)}
+}
+
+// newNonJSONResponseError describes a successful response which could not be decoded, e.g. an HTML page of a proxy.
+func newNonJSONResponseError(request *http.Request, response *http.Response, body []byte, secrets []string, err error) error {
+	description := fmt.Sprintf("%s %s returned an invalid JSON response, status: %s", request.Method, request.URL.Path, response.Status)
+	if !isJSONResponse(response, body) {
+		description = fmt.Sprintf("%s %s returned a non-JSON response (%s), status: %s", request.Method, request.URL.Path, responseContentType(response), response.Status)
+	} else if err != nil {
+		description += ", error: " + err.Error()
+	}
+
+	if excerpt := responseExcerpt(body, request, secrets); excerpt != "" {
+		description += ", response: " + excerpt
+	}
+	description += "\nA proxy or load balancer may have answered instead of the Bitrise API, check the API base URL and the proxy settings."
+	return &stepError{category: errorCategoryNetwork, err: fmt.Errorf("%s", description)}
+}
+
+// responseErrorCategory returns the error category of an unsuccessful response status:
+// requests refused because of the inputs are config or rejected errors, the others are network errors.
+func responseErrorCategory(statusCode int) string {
+	switch {
+	case statusCode == http.StatusNotFound:
+		return errorCategoryConfig
+	case statusCode == http.StatusTooManyRequests || statusCode < 400 || statusCode >= 500:
+		return httpStatusErrorCategory(statusCode)
+	default:
+		if category := httpStatusErrorCategory(statusCode); category != errorCategoryNetwork {
+			return category
+		}
+		return errorCategoryRejected
+	}
+}
+
+// responseGuidance returns the likely cause of an unsuccessful response status and how to fix it.
+func responseGuidance(statusCode int) string {
+	switch {
+	case statusCode == http.StatusUnauthorized || statusCode == http.StatusForbidden:
+		return "The Bitrise API refused the token: check that the Build Trigger API token or the Access Token is valid, not expired and has access to the app."
+	case statusCode == http.StatusNotFound:
+		return "The Bitrise API did not find the resource: check the app slug and the workflow ID, and that the token has access to the app."
+	case statusCode == http.StatusTooManyRequests:
+		return "The Bitrise API rate limited the requests: increase the poll interval, trigger fewer builds at once or retry later."
+	case statusCode >= 500:
+		return "The Bitrise API is unavailable: check https://status.bitrise.io and retry later."
+	default:
+		return ""
+	}
+}
+
+// apiErrorMessage returns the error message of a JSON error body, or an empty string.
+func apiErrorMessage(response *http.Response, body []byte) string {
+	if !isJSONResponse(response, body) {
+		return ""
+	}
+
+	var errorModel APIErrorResponseModel
+	if err := json.Unmarshal(body, &errorModel); err != nil {
+		return ""
+	}
+	if errorModel.ErrorMessage != "" {
+		return errorModel.ErrorMessage
+	}
+	return errorModel.Message
+}
+
+func isJSONResponse(response *http.Response, body []byte) bool {
+	if strings.Contains(responseContentType(response), "json") {
+		return true
+	}
+	trimmed := bytes.TrimSpace(body)
+	return len(trimmed) > 0 && (trimmed[0] == '{' || trimmed[0] == '[')
+}
+
+func responseContentType(response *http.Response) string {
+	if contentType := response.Header.Get("Content-Type"); contentType != "" {
+		return contentType
+	}
+	return "no content type"
+}
+
+// responseExcerpt returns the beginning of the body on a single line, with the secrets redacted.
+func responseExcerpt(body []byte, request *http.Request, secrets []string) string {
+	excerpt := []rune(redactSecrets(strings.Join(strings.Fields(string(body)), " "), request, secrets))
+	if len(excerpt) > maxResponseExcerptLength {
+		return string(excerpt[:maxResponseExcerptLength]) + "..."
+	}
+	return string(excerpt)
+}
+
+// redactSecrets hides the token sent in the Authorization header, the known secret values (e.g. the tokens of the inputs)
+// and the values of secret looking fields.
+func redactSecrets(text string, request *http.Request, secrets []string) string {
+	if authorization := request.Header.Get("Authorization"); authorization != "" {
+		text = strings.Replace(text, authorization, "[REDACTED]", -1)
+	}
+	for _, secret := range secrets {
+		if secret != "" {
+			text = strings.Replace(text, secret, "[REDACTED]", -1)
+		}
+	}
+	return secretFieldPattern.ReplaceAllString(text, "${1}[REDACTED]")
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTriggerBuildResponseDiagnostics(t *testing.T) {
+	var statusCode int
+	var contentType, body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if contentType != "" {
+			w.Header().Set("Content-Type", contentType)
+		}
+		w.WriteHeader(statusCode)
+		_, err := w.Write([]byte(body))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{AppSlug: "app", AccessToken: "secret-access-token", APIProtocol: apiProtocolREST, APIBaseURL: server.URL, RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	target := TriggerTargetModel{AppSlug: "app", WorkflowID: "primary"}
+
+	for _, tc := range []struct {
+		statusCode  int
+		contentType string
+		body        string
+		category    string
+		contains    []string
+		notContains []string
+	}{
+		{
+			statusCode: http.StatusUnauthorized, contentType: "application/json", body: `{"message":"Unauthorized"}`,
+			category: errorCategoryAuth, contains: []string{"401 Unauthorized, message: Unauthorized", "refused the token"},
+		},
+		{
+			statusCode: http.StatusNotFound, contentType: "application/json", body: `{"error_msg":"Not Found"}`,
+			category: errorCategoryConfig, contains: []string{"message: Not Found", "check the app slug"},
+		},
+		{
+			statusCode: http.StatusTooManyRequests, body: "slow down",
+			category: errorCategoryNetwork, contains: []string{"response: slow down", "rate limited"},
+		},
+		{
+			statusCode: http.StatusBadGateway, contentType: "text/html", body: "<html>\n<body>502 Bad Gateway, token=secret-access-token</body>\n</html>",
+			category: errorCategoryNetwork, contains: []string{"response: <html> <body>502 Bad Gateway, token=[REDACTED]", "status.bitrise.io"},
+			notContains: []string{"secret-access-token"},
+		},
+		{
+			statusCode: http.StatusBadRequest, contentType: "application/json", body: `{"message":"workflow (nightly) not found"}`,
+			category: errorCategoryRejected, contains: []string{"message: workflow (nightly) not found"},
+		},
+		{
+			statusCode: http.StatusOK, contentType: "text/html", body: "<html>login</html>",
+			category: errorCategoryNetwork, contains: []string{"non-JSON response (text/html)", "response: <html>login</html>"},
+		},
+	} {
+		statusCode, contentType, body = tc.statusCode, tc.contentType, tc.body
+		err := triggerBuild(client, configs, target).err
+		require.Error(t, err)
+		require.Equal(t, tc.category, errorCategory(err), err.Error())
+		for _, text := range tc.contains {
+			require.Contains(t, err.Error(), text)
+		}
+		for _, text := range tc.notContains {
+			require.NotContains(t, err.Error(), text)
+		}
+	}
+}
+
+func TestResponseExcerptTruncated(t *testing.T) {
+	request, err := http.NewRequest("GET", "https://api.bitrise.io/v0.1/apps", nil)
+	require.NoError(t, err)
+
+	excerpt := responseExcerpt([]byte(strings.Repeat("é", 300)), request, nil)
+	require.Equal(t, strings.Repeat("é", maxResponseExcerptLength)+"...", excerpt)
+}
+
+func TestRedactSecrets(t *testing.T) {
+	request, err := http.NewRequest("GET", "https://api.bitrise.io/v0.1/apps", nil)
+	require.NoError(t, err)
+	request.Header.Set("Authorization", "header-token")
+
+	require.Equal(t, `{"api_token":"[REDACTED]","build":"x"} [REDACTED]`, redactSecrets(`{"api_token":"abc","build":"x"} header-token`, request, nil))
+	require.Equal(t, `{"hook_info":{"type":"bitrise","api_token": "[REDACTED]"}} tokens: '[REDACTED]' ([REDACTED])`,
+		redactSecrets(`{"hook_info":{"type":"bitrise","api_token": "trigger-token"}} tokens: 'trigger-token' (other-app-token)`, request, []string{"trigger-token", "other-app-token"}))
+}
+
+func TestTriggerBuildRedactsTokens(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+		_, err := w.Write([]byte(`{"message":"invalid hook info: trigger-token, other apps: other-app-token"}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	require.NoError(t, os.Setenv("TRIGGER_STEP_TEST_OTHER_TOKEN", "other-app-token"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("TRIGGER_STEP_TEST_OTHER_TOKEN"))
+	}()
+
+	configs := ConfigsModel{AppSlug: "app", APIToken: "trigger-token", AdditionalApps: "other:TRIGGER_STEP_TEST_OTHER_TOKEN", APIBaseURL: server.URL, RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	err = triggerBuild(client, configs, TriggerTargetModel{AppSlug: "app", APIToken: "trigger-token", WorkflowID: "primary"}).err
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "message: invalid hook info: [REDACTED], other apps: [REDACTED]")
+	require.NotContains(t, err.Error(), "trigger-token")
+	require.NotContains(t, err.Error(), "other-app-token")
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+)
+
+// Error categories are exported in the TRIGGER_ERROR_CATEGORY output, each of them exits the step with its own code.
+const (
+	errorCategoryConfig      = "config"
+	errorCategoryInternal    = "internal"
+	errorCategoryNetwork     = "network"
+	errorCategoryRejected    = "rejected"
+	errorCategoryExport      = "export"
+	errorCategoryChildFailed = "child_failed"
+	errorCategoryInterrupted = "interrupted"
+	errorCategoryAuth        = "auth"
+	errorCategoryTimeout     = "timeout"
+)
+
+var errorCategoryExitCodes = map[string]int{
+	errorCategoryConfig:      1,
+	errorCategoryInternal:    2,
+	errorCategoryNetwork:     3,
+	errorCategoryRejected:    4,
+	errorCategoryExport:      5,
+	errorCategoryChildFailed: 6,
+	errorCategoryInterrupted: 7,
+	errorCategoryAuth:        8,
+	errorCategoryTimeout:     9,
+}
+
+// stepError is an error with a category, which determines the exit code of the step.
+type stepError struct {
+	category string
+	err      error
+}
+
+func (stepErr *stepError) Error() string {
+	return stepErr.err.Error()
+}
+
+func (stepErr *stepError) Unwrap() error {
+	return stepErr.err
+}
+
+func newStepError(category, format string, args ...interface{}) error {
+	return &stepError{category: category, err: fmt.Errorf(format, args...)}
+}
+
+// categorize returns err with the given category, unless it is already categorized.
+// Timeouts are categorized as such regardless of the given category.
+func categorize(category string, err error) error {
+	var stepErr *stepError
+	if err == nil || errors.As(err, &stepErr) {
+		return err
+	}
+
+	if isTimeoutError(err) {
+		category = errorCategoryTimeout
+	}
+	return &stepError{category: category, err: err}
+}
+
+// errorCategory returns the category of err, errors which are not categorized are internal errors.
+func errorCategory(err error) string {
+	var stepErr *stepError
+	if errors.As(err, &stepErr) {
+		return stepErr.category
+	}
+	if isTimeoutError(err) {
+		return errorCategoryTimeout
+	}
+	return errorCategoryInternal
+}
+
+func exitCode(category string) int {
+	if code, ok := errorCategoryExitCodes[category]; ok {
+		return code
+	}
+	return errorCategoryExitCodes[errorCategoryInternal]
+}
+
+func isTimeoutError(err error) bool {
+	if errors.Is(err, context.DeadlineExceeded) {
+		return true
+	}
+
+	var netError net.Error
+	return errors.As(err, &netError) && netError.Timeout()
+}
+
+// httpStatusErrorCategory returns the category of an unsuccessful HTTP response status.
+func httpStatusErrorCategory(statusCode int) string {
+	switch statusCode {
+	case http.StatusUnauthorized, http.StatusForbidden:
+		return errorCategoryAuth
+	case http.StatusRequestTimeout, http.StatusGatewayTimeout:
+		return errorCategoryTimeout
+	default:
+		return errorCategoryNetwork
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestErrorCategory(t *testing.T) {
+	require.Equal(t, errorCategoryInternal, errorCategory(errors.New("unexpected")))
+	require.Equal(t, errorCategoryRejected, errorCategory(newStepError(errorCategoryRejected, "build not triggered")))
+	require.Equal(t, errorCategoryTimeout, errorCategory(fmt.Errorf("request failed: %w", context.DeadlineExceeded)))
+
+	authErr := newStepError(errorCategoryAuth, "not authorized")
+	require.Equal(t, errorCategoryAuth, errorCategory(categorize(errorCategoryNetwork, fmt.Errorf("could not send request, error: %w", authErr))))
+	require.Equal(t, errorCategoryNetwork, errorCategory(categorize(errorCategoryNetwork, errors.New("connection refused"))))
+	require.Nil(t, categorize(errorCategoryNetwork, nil))
+}
+
+func TestExitCode(t *testing.T) {
+	require.Equal(t, 1, exitCode(errorCategoryConfig))
+	require.Equal(t, 3, exitCode(errorCategoryNetwork))
+	require.Equal(t, 6, exitCode(errorCategoryChildFailed))
+	require.Equal(t, 8, exitCode(errorCategoryAuth))
+	require.Equal(t, 2, exitCode("unknown"))
+}
+
+func TestTriggerBuildErrorCategories(t *testing.T) {
+	var statusCode int
+	var body string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(statusCode)
+		_, err := w.Write([]byte(body))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{AppSlug: "app", APIToken: "token", APIBaseURL: server.URL, RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	target := TriggerTargetModel{AppSlug: "app", APIToken: "token", WorkflowID: "primary"}
+
+	statusCode, body = http.StatusUnauthorized, `{"status":"error","message":"unauthorized"}`
+	require.Equal(t, errorCategoryAuth, errorCategory(triggerBuild(client, configs, target).err))
+
+	statusCode, body = http.StatusBadRequest, `{"status":"error","message":"workflow not found"}`
+	require.Equal(t, errorCategoryRejected, errorCategory(triggerBuild(client, configs, target).err))
+
+	statusCode, body = http.StatusCreated, `{"status":"ok","build_slug":"build"}`
+	require.NoError(t, triggerBuild(client, configs, target).err)
+}
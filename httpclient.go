@@ -0,0 +1,104 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+)
+
+const (
+	defaultConnectTimeout  = 10 * time.Second
+	defaultResponseTimeout = 60 * time.Second
+)
+
+// newHTTPClient creates the HTTP client shared by every request of the step.
+// The response timeout limits the wait for the response headers only, so large downloads are not cut off.
+func newHTTPClient(configs ConfigsModel) (*http.Client, error) {
+	tlsConfig := &tls.Config{}
+	if configs.CABundlePath != "" {
+		rootCAs, err := loadCABundle(configs.CABundlePath)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.RootCAs = rootCAs
+	}
+
+	connectTimeout := configs.connectTimeout()
+	transport := &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		DialContext: (&net.Dialer{
+			Timeout:   connectTimeout,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		TLSClientConfig:       tlsConfig,
+		TLSHandshakeTimeout:   connectTimeout,
+		ResponseHeaderTimeout: configs.responseTimeout(),
+		ExpectContinueTimeout: time.Second,
+		IdleConnTimeout:       90 * time.Second,
+		MaxIdleConns:          100,
+		ForceAttemptHTTP2:     true,
+	}
+	return &http.Client{Transport: transport}, nil
+}
+
+// loadCABundle returns the system root certificates extended with the PEM encoded certificates of the bundle.
+func loadCABundle(pth string) (*x509.CertPool, error) {
+	contents, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read CA bundle: %s", err)
+	}
+
+	rootCAs, err := x509.SystemCertPool()
+	if err != nil || rootCAs == nil {
+		rootCAs = x509.NewCertPool()
+	}
+	if !rootCAs.AppendCertsFromPEM(contents) {
+		return nil, fmt.Errorf("no PEM encoded certificate found in CA bundle: %s", pth)
+	}
+	return rootCAs, nil
+}
+
+// logProxySettings prints the proxy used for each API base URL, based on the HTTPS_PROXY, HTTP_PROXY and NO_PROXY environment variables.
+func logProxySettings(client apiClient) {
+	baseURLs := []string{client.appBaseURL}
+	if client.apiBaseURL != client.appBaseURL {
+		baseURLs = append(baseURLs, client.apiBaseURL)
+	}
+
+	for _, baseURL := range baseURLs {
+		request, err := http.NewRequest("GET", baseURL, nil)
+		if err != nil {
+			continue
+		}
+
+		proxyURL, err := http.ProxyFromEnvironment(request)
+		switch {
+		case err != nil:
+			logger.Warnf("Invalid proxy configuration for %s, error: %s", baseURL, err)
+		case proxyURL != nil:
+			logger.Printf("Using proxy %s for %s", proxyURL.Redacted(), baseURL)
+		case isProxyConfigured(request.URL.Scheme):
+			logger.Printf("Not using proxy for %s, excluded by NO_PROXY", baseURL)
+		}
+	}
+}
+
+// isProxyConfigured reports whether a proxy is set for the URL scheme in the environment.
+func isProxyConfigured(scheme string) bool {
+	keys := []string{"HTTP_PROXY", "http_proxy"}
+	if scheme == "https" {
+		keys = []string{"HTTPS_PROXY", "https_proxy"}
+	}
+
+	for _, key := range keys {
+		if os.Getenv(key) != "" {
+			return true
+		}
+	}
+	return false
+}
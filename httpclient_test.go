@@ -0,0 +1,83 @@
+package main
+
+import (
+	"encoding/pem"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewHTTPClientCABundle(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	bundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	certificate := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: server.Certificate().Raw})
+	require.NoError(t, ioutil.WriteFile(bundlePath, certificate, 0600))
+
+	client, err := newHTTPClient(ConfigsModel{})
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+
+	client, err = newHTTPClient(ConfigsModel{CABundlePath: bundlePath})
+	require.NoError(t, err)
+	response, err := client.Get(server.URL)
+	require.NoError(t, err)
+	require.NoError(t, response.Body.Close())
+}
+
+func TestNewHTTPClientResponseTimeout(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(1500 * time.Millisecond)
+	}))
+	defer server.Close()
+
+	client, err := newHTTPClient(ConfigsModel{ResponseTimeout: "1"})
+	require.NoError(t, err)
+	_, err = client.Get(server.URL)
+	require.Error(t, err)
+	require.Equal(t, errorCategoryTimeout, errorCategory(categorize(errorCategoryNetwork, err)))
+}
+
+func TestValidateConfigsInvalidCABundle(t *testing.T) {
+	bundlePath := filepath.Join(t.TempDir(), "ca.pem")
+	require.NoError(t, ioutil.WriteFile(bundlePath, []byte("not a certificate"), 0600))
+
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		CABundlePath: bundlePath,
+	}
+	err := configs.validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "no PEM encoded certificate found in CA bundle")
+
+	configs.CABundlePath = filepath.Join(t.TempDir(), "missing.pem")
+	err = configs.validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "failed to read CA bundle")
+}
+
+func TestValidateConfigsInvalidTimeouts(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:       "token",
+		AppSlug:        "slug",
+		ConnectTimeout: "0",
+	}
+	require.EqualError(t, configs.validate(), "invalid connect timeout specified: value must be positive, got: 0")
+
+	configs.ConnectTimeout = "5"
+	configs.ResponseTimeout = "soon"
+	require.EqualError(t, configs.validate(), "invalid response timeout specified: strconv.Atoi: parsing \"soon\": invalid syntax")
+
+	configs.ResponseTimeout = "30"
+	require.NoError(t, configs.validate())
+	require.Equal(t, 5*time.Second, configs.connectTimeout())
+	require.Equal(t, 30*time.Second, configs.responseTimeout())
+}
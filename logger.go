@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+)
+
+const (
+	logFormatText = "text"
+	logFormatJSON = "json"
+
+	logLevelInfo   = "info"
+	logLevelNormal = "normal"
+	logLevelWarn   = "warn"
+	logLevelError  = "error"
+	logLevelDone   = "done"
+)
+
+// logFields are the structured data of a log event, keyed by snake_case field names.
+type logFields map[string]interface{}
+
+// dumpField is a value printed as ` - <label>: <value>` in text mode and as the <key> field in JSON mode.
+type dumpField struct {
+	label string
+	key   string
+	value interface{}
+}
+
+// stepLogger prints the step output either as plain text or as structured JSON events, one per line.
+type stepLogger interface {
+	Infof(format string, v ...interface{})
+	Printf(format string, v ...interface{})
+	Warnf(format string, v ...interface{})
+	Errorf(format string, v ...interface{})
+	Donef(format string, v ...interface{})
+	Debugf(format string, v ...interface{})
+	// Event prints a message with structured data at the given level. In text mode only the message is printed.
+	Event(level, name string, fields logFields, format string, v ...interface{})
+	// Dump prints the fields as a list under the title in text mode, or as a single event in JSON mode.
+	Dump(name, title string, fields []dumpField)
+	// Section separates the next part of the output in text mode.
+	Section()
+}
+
+var logger stepLogger = textLogger{}
+
+func newStepLogger(format string) stepLogger {
+	if format == logFormatJSON {
+		return jsonLogger{logger: log.NewDefaultJSONLoger()}
+	}
+	return textLogger{}
+}
+
+type textLogger struct{}
+
+func (textLogger) Infof(format string, v ...interface{})  { log.Infof(format, v...) }
+func (textLogger) Printf(format string, v ...interface{}) { log.Printf(format, v...) }
+func (textLogger) Warnf(format string, v ...interface{})  { log.Warnf(format, v...) }
+func (textLogger) Errorf(format string, v ...interface{}) { log.Errorf(format, v...) }
+func (textLogger) Donef(format string, v ...interface{})  { log.Donef(format, v...) }
+func (textLogger) Debugf(format string, v ...interface{}) { log.Debugf(format, v...) }
+func (textLogger) Section()                               { fmt.Println() }
+
+func (l textLogger) Event(level, name string, fields logFields, format string, v ...interface{}) {
+	switch level {
+	case logLevelInfo:
+		l.Infof(format, v...)
+	case logLevelWarn:
+		l.Warnf(format, v...)
+	case logLevelError:
+		l.Errorf(format, v...)
+	case logLevelDone:
+		l.Donef(format, v...)
+	default:
+		l.Printf(format, v...)
+	}
+}
+
+func (textLogger) Dump(name, title string, fields []dumpField) {
+	log.Infof("%s", title)
+	for _, field := range fields {
+		log.Printf(" - %s: %v", field.label, field.value)
+	}
+}
+
+type jsonLogger struct {
+	logger log.JSONLoger
+}
+
+func (l jsonLogger) Infof(format string, v ...interface{}) {
+	l.print(logLevelInfo, "message", nil, format, v...)
+}
+
+func (l jsonLogger) Printf(format string, v ...interface{}) {
+	l.print(logLevelNormal, "message", nil, format, v...)
+}
+
+func (l jsonLogger) Warnf(format string, v ...interface{}) {
+	l.print(logLevelWarn, "message", nil, format, v...)
+}
+
+func (l jsonLogger) Errorf(format string, v ...interface{}) {
+	l.print(logLevelError, "message", nil, format, v...)
+}
+
+func (l jsonLogger) Donef(format string, v ...interface{}) {
+	l.print(logLevelDone, "message", nil, format, v...)
+}
+
+// Debugf prints nothing, debug logging is disabled in text mode as well.
+func (jsonLogger) Debugf(format string, v ...interface{}) {}
+
+func (jsonLogger) Section() {}
+
+func (l jsonLogger) Event(level, name string, fields logFields, format string, v ...interface{}) {
+	l.print(level, name, fields, format, v...)
+}
+
+func (l jsonLogger) Dump(name, title string, fields []dumpField) {
+	data := logFields{}
+	for _, field := range fields {
+		data[field.key] = field.value
+	}
+	l.print(logLevelInfo, name, data, "%s", title)
+}
+
+func (l jsonLogger) print(level, name string, fields logFields, format string, v ...interface{}) {
+	l.logger.Print(logEvent{
+		Timestamp: time.Now().UTC().Format(time.RFC3339Nano),
+		Level:     level,
+		Event:     name,
+		Message:   fmt.Sprintf(format, v...),
+		Data:      fields,
+	})
+}
+
+// buildLogFields describes a triggered build in structured log events.
+func buildLogFields(build TriggeredBuildModel) logFields {
+	fields := logFields{
+		"app_slug":     build.AppSlug,
+		"workflow_id":  build.WorkflowID,
+		"build_slug":   build.BuildSlug,
+		"build_number": build.BuildNumber,
+		"build_url":    build.BuildURL,
+		"status":       build.Status,
+	}
+	if build.Matrix != nil {
+		fields["matrix"] = build.Matrix
+	}
+	if build.PipelineID != "" {
+		fields["pipeline"] = build.Pipeline
+		fields["pipeline_id"] = build.PipelineID
+		fields["stages"] = build.Stages
+	}
+	return fields
+}
+
+// logEvent is a structured log line in JSON log format.
+type logEvent struct {
+	Timestamp string    `json:"timestamp"`
+	Level     string    `json:"level"`
+	Event     string    `json:"event"`
+	Message   string    `json:"message"`
+	Data      logFields `json:"data,omitempty"`
+}
+
+// String ...
+func (event logEvent) String() string {
+	return event.Message
+}
+
+// JSON ...
+func (event logEvent) JSON() string {
+	contents, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Sprintf(`{"level":"error","event":"message","message":"failed to serialize log event: %s"}`+"\n", err)
+	}
+	return string(contents) + "\n"
+}
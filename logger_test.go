@@ -0,0 +1,46 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bitrise-io/go-utils/log"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONLoggerEvent(t *testing.T) {
+	var buffer bytes.Buffer
+	jsonLogger := jsonLogger{logger: *log.NewJSONLoger(&buffer)}
+
+	jsonLogger.Event(logLevelInfo, "build_finished", logFields{"build_slug": "build", "build_number": 12}, "Triggered build %s status: %s", "build", "success")
+	jsonLogger.Dump("config", "Configs:", []dumpField{{"AppSlug", "app_slug", "app"}})
+	jsonLogger.Section()
+
+	lines := strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n")
+	require.Len(t, lines, 2)
+
+	var event map[string]interface{}
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &event))
+	require.Equal(t, "info", event["level"])
+	require.Equal(t, "build_finished", event["event"])
+	require.Equal(t, "Triggered build build status: success", event["message"])
+	require.Equal(t, map[string]interface{}{"build_slug": "build", "build_number": float64(12)}, event["data"])
+	_, err := time.Parse(time.RFC3339Nano, event["timestamp"].(string))
+	require.NoError(t, err)
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &event))
+	require.Equal(t, "config", event["event"])
+	require.Equal(t, map[string]interface{}{"app_slug": "app"}, event["data"])
+}
+
+func TestValidateConfigsInvalidLogFormat(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:  "token",
+		AppSlug:   "slug",
+		LogFormat: "xml",
+	}
+	require.EqualError(t, configs.validate(), "invalid log format specified: xml, allowed: text, json")
+}
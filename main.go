@@ -7,9 +7,8 @@ import (
 	"io/ioutil"
 	"net/http"
 	"os"
-	"strconv"
-
-	"github.com/bitrise-io/go-utils/log"
+	"strings"
+	"sync"
 )
 
 const (
@@ -17,96 +16,469 @@ const (
 	triggeredBuildNumber = "TRIGGERED_BUILD_NUMBER"
 	triggeredBuildURL    = "TRIGGERED_BUILD_URL"
 	triggeredWorkflowID  = "TRIGGERED_WORKFLOW_ID"
+	triggeredBuildStatus = "TRIGGERED_BUILD_STATUS"
+	triggeredBuilds      = "TRIGGERED_BUILDS"
+
+	triggeredBuildsMatrix       = "TRIGGERED_BUILDS_MATRIX"
+	triggeredBuildArtifactPaths = "TRIGGERED_BUILD_ARTIFACT_PATHS"
+	triggeredPipelineID         = "TRIGGERED_PIPELINE_ID"
+	triggeredPipelineURL        = "TRIGGERED_PIPELINE_URL"
+	triggerErrorCategory        = "TRIGGER_ERROR_CATEGORY"
 )
 
+// triggerResult describes the builds started by a trigger request: the first one and the other results, if any.
+type triggerResult struct {
+	build        TriggeredBuildModel
+	otherResults []TriggeredBuildModel
+	err          error
+}
+
 func main() {
+	if err := run(); err != nil {
+		category := errorCategory(err)
+		logger.Event(logLevelError, "error", logFields{"category": category, "exit_code": exitCode(category)}, "%s", err)
+
+		if err := exportEnvironmentWithEnvman(triggerErrorCategory, category); err != nil {
+			logger.Warnf("Could not export error category: %s", err)
+		}
+		os.Exit(exitCode(category))
+	}
+}
+
+func run() error {
 	configs := createConfigsModelFromEnvs()
+	logger = newStepLogger(configs.LogFormat)
 	configs.dump()
 	if err := configs.validate(); err != nil {
-		log.Errorf("Issue with input: %s", err)
-		os.Exit(1)
+		return categorize(errorCategoryConfig, fmt.Errorf("Issue with input: %w", err))
 	}
 
-	requestBody, err := createRequestBodyFromConfigs(configs)
+	client, err := newAPIClient(configs)
 	if err != nil {
-		log.Errorf("Could not create request body, error: %s", err)
-		os.Exit(2)
+		return newStepError(errorCategoryConfig, "Issue with input: %s", err)
+	}
+	logProxySettings(client)
+
+	if configs.TriggerMapResolution == triggerMapResolutionShow || configs.TriggerMapResolution == triggerMapResolutionLock {
+		logger.Section()
+		logger.Infof("Resolving the workflow from the trigger map of %s for %s", configs.BitriseYMLPath, configs.triggerEventDescription())
+		item, err := resolveWorkflowFromTriggerMap(configs)
+		if err != nil {
+			if configs.TriggerMapResolution == triggerMapResolutionLock {
+				return newStepError(errorCategoryConfig, "Could not resolve the workflow from the trigger map, error: %s", err)
+			}
+			logger.Warnf("Could not predict the workflow from the trigger map, error: %s", err)
+		} else if item.WorkflowID == "" {
+			if configs.TriggerMapResolution == triggerMapResolutionLock {
+				logger.Donef("Locked in pipeline: %s", item.PipelineID)
+				configs.PipelineID = item.PipelineID
+				if err := configs.validatePipeline(); err != nil {
+					return newStepError(errorCategoryConfig, "Could not lock in pipeline %s, error: %s", item.PipelineID, err)
+				}
+			} else {
+				logger.Printf("Predicted pipeline: %s", item.PipelineID)
+			}
+		} else if configs.TriggerMapResolution == triggerMapResolutionLock {
+			logger.Donef("Locked in workflow: %s", item.WorkflowID)
+			configs.WorkflowID = item.WorkflowID
+		} else {
+			logger.Printf("Predicted workflow: %s", item.WorkflowID)
+		}
+	}
+
+	// Validated after the trigger map resolution, so the locked in workflow is checked too.
+	if configs.isValidateWorkflows() {
+		rules, err := parsePathRules(configs.PathRules)
+		if err != nil {
+			return newStepError(errorCategoryConfig, "Issue with input: %s", err)
+		}
+		if err := configs.validateWorkflowsDefined(client, rules); err != nil {
+			return categorize(errorCategoryConfig, fmt.Errorf("Issue with input: %w", err))
+		}
 	}
 
-	request, err := createRequest(configs.AppSlug, requestBody)
+	if configs.PathRules != "" {
+		logger.Section()
+		logger.Infof("Selecting workflows by the files changed since %s", configs.changesBaseRef())
+		workflowIDs, err := selectWorkflowsByPathRules(configs)
+		if err != nil {
+			return newStepError(errorCategoryInternal, "Could not select workflows by path rules, error: %s", err)
+		}
+
+		if len(workflowIDs) == 0 {
+			logger.Warnf("No path rule matched the changed files, not triggering any build")
+			return nil
+		}
+		logger.Infof("Selected workflow(s): %s", strings.Join(workflowIDs, ", "))
+		configs.WorkflowID = strings.Join(workflowIDs, "|")
+	}
+
+	targets, err := configs.triggerTargets()
 	if err != nil {
-		log.Errorf("Could not create request, error: %s", err)
-		os.Exit(2)
+		return newStepError(errorCategoryConfig, "Issue with input: %s", err)
+	}
+
+	if err := configs.validateTriggerTargets(targets); err != nil {
+		return newStepError(errorCategoryConfig, "Issue with input: %s", err)
 	}
 
-	responseModel, err := performRequest(request)
+	results := triggerBuilds(client, configs, targets)
+
+	builds, err := collectTriggerResults(results)
 	if err != nil {
-		log.Errorf("Could not send request, error: %s", err)
-		os.Exit(3)
+		// The builds started by the other targets keep running, so they are exported to be visible to the next steps.
+		for _, build := range builds {
+			logger.Warnf("Build %s (%s) was triggered despite the failure: %s", build.BuildSlug, buildDisplayName(build), build.BuildURL)
+		}
+		if exportErr := exportTriggeredBuilds(builds); exportErr != nil {
+			logger.Warnf("Could not export triggered builds: %s", exportErr)
+		}
+		return err
+	}
+
+	if configs.isCancelSupersededBuilds() {
+		cancelAllSupersededBuilds(client, configs, builds)
 	}
 
-	log.Infof("Build Trigger status: %s", responseModel.Status)
+	for _, build := range builds {
+		logger.Section()
+		if build.PipelineID != "" {
+			logger.Dump("pipeline_triggered", "Triggered pipeline:", []dumpField{
+				{"ID", "pipeline_id", build.PipelineID},
+				{"URL", "pipeline_url", build.BuildURL},
+				{"Pipeline", "pipeline", build.Pipeline},
+				{"App slug", "app_slug", build.AppSlug},
+			})
+			continue
+		}
+		logger.Dump("build_triggered", "Triggered build:", []dumpField{
+			{"Slug", "build_slug", build.BuildSlug},
+			{"Number", "build_number", build.BuildNumber},
+			{"URL", "build_url", build.BuildURL},
+			{"Workflow ID", "workflow_id", build.WorkflowID},
+			{"App slug", "app_slug", build.AppSlug},
+			{"Reused", "reused", build.Reused},
+		})
+	}
 
-	if responseModel.Message != "ok" {
-		log.Errorf("Build not triggered, status: %s", responseModel.Message)
-		os.Exit(4)
+	if err := exportTriggeredBuilds(builds); err != nil {
+		return newStepError(errorCategoryExport, "Could not export triggered builds: %s", err)
+	}
+
+	if !configs.isWaitForBuild() {
+		return nil
+	}
+
+	ctx, cancel := cancelOnSignal()
+	defer cancel()
+
+	waitedBuilds := builds
+	if !configs.isWaitForAllResults() {
+		waitedBuilds = firstResults(builds)
+	}
+
+	logger.Section()
+	logger.Infof("Waiting for %d build(s) to finish", len(waitedBuilds))
+	waitedBuilds, err = waitForBuilds(ctx, client, configs, waitedBuilds)
+	if ctx.Err() != nil {
+		logger.Warnf("Step was interrupted, aborting triggered builds")
+		// Builds which were not waited for (e.g. other results of a trigger request) are still running too.
+		abortBuilds(client, updateBuildStatuses(builds, waitedBuilds), configs.AbortReason)
+		return newStepError(errorCategoryInterrupted, "Step was interrupted")
+	}
+	// The builds have finished, so from now on a signal terminates the step instead of being ignored.
+	cancel()
+	if err != nil {
+		return categorize(errorCategoryNetwork, fmt.Errorf("Could not get triggered build status, error: %w", err))
+	}
+	builds = updateBuildStatuses(builds, waitedBuilds)
+
+	for _, build := range waitedBuilds {
+		if build.PipelineID != "" {
+			logger.Event(logLevelInfo, "pipeline_finished", buildLogFields(build), "Triggered pipeline %s (%s) status: %s", build.PipelineID, build.Pipeline, build.Status)
+			continue
+		}
+		logger.Event(logLevelInfo, "build_finished", buildLogFields(build), "Triggered build %s (%s) status: %s", build.BuildSlug, build.WorkflowID, build.Status)
+	}
+
+	if configs.BuildLogMode == buildLogModeOnFailure {
+		for _, build := range waitedBuilds {
+			if build.Status == buildStatusSuccess || build.Status == buildStatusAbortedFailFast || build.PipelineID != "" {
+				continue
+			}
+
+			logger.Section()
+			logger.Infof("Last %d lines of build %s log:", configs.buildLogLines(), build.BuildSlug)
+			if err := printBuildLogTail(client, build, configs.buildLogLines()); err != nil {
+				logger.Warnf("Could not get log of build %s, error: %s", build.BuildSlug, err)
+			}
+		}
+	}
+
+	if err := exportTriggeredBuilds(builds); err != nil {
+		return newStepError(errorCategoryExport, "Could not export triggered builds: %s", err)
+	}
+
+	if configs.isDownloadArtifacts() {
+		logger.Section()
+		logger.Infof("Downloading artifacts to %s", configs.ArtifactsDir)
+		paths, err := downloadArtifacts(client, waitedBuilds, configs.artifactPatterns(), configs.ArtifactsDir)
+		if err != nil {
+			return categorize(errorCategoryNetwork, fmt.Errorf("Could not download artifacts, error: %w", err))
+		}
+
+		if err := exportEnvironmentWithEnvman(triggeredBuildArtifactPaths, strings.Join(paths, "|")); err != nil {
+			return newStepError(errorCategoryExport, "Could not export artifact paths: %s", err)
+		}
+	}
+
+	policy, err := parseResultPolicy(configs.ResultPolicy)
+	if err != nil {
+		return newStepError(errorCategoryConfig, "Issue with input: %s", err)
+	}
+
+	printSummary(waitedBuilds, policy)
+
+	if !policy.isSatisfied(waitedBuilds) {
+		return newStepError(errorCategoryChildFailed, "Triggered builds do not satisfy the result policy: %s", policy.name)
+	}
+
+	logger.Donef("Triggered builds satisfy the result policy: %s", policy.name)
+	return nil
+}
+
+// triggerBuilds starts one build per target concurrently. Results are returned in the order of targets.
+func triggerBuilds(client apiClient, configs ConfigsModel, targets []TriggerTargetModel) []triggerResult {
+	results := make([]triggerResult, len(targets))
+
+	var wg sync.WaitGroup
+	for i, target := range targets {
+		wg.Add(1)
+		go func(i int, target TriggerTargetModel) {
+			defer wg.Done()
+			results[i] = triggerBuild(client, configs, target)
+		}(i, target)
+	}
+	wg.Wait()
+
+	return results
+}
+
+// collectTriggerResults returns every build started by the trigger requests, including the ones started
+// before another request failed, and an error describing the failed requests, if any.
+func collectTriggerResults(results []triggerResult) ([]TriggeredBuildModel, error) {
+	builds := []TriggeredBuildModel{}
+	var triggerErr error
+	failedCount := 0
+	for _, result := range results {
+		if result.err != nil {
+			err := fmt.Errorf("Could not trigger %s of app %s, error: %w", triggerTargetName(result.build), result.build.AppSlug, result.err)
+			if failedCount > 0 {
+				logger.Errorf("%s", err)
+			}
+			if triggerErr == nil {
+				triggerErr = err
+			}
+			failedCount++
+			continue
+		}
+		builds = append(builds, result.build)
+		builds = append(builds, result.otherResults...)
+	}
+
+	if failedCount > 1 {
+		return builds, fmt.Errorf("%d of %d trigger requests failed, first error: %w", failedCount, len(results), triggerErr)
+	}
+	return builds, triggerErr
+}
+
+func triggerBuild(client apiClient, configs ConfigsModel, target TriggerTargetModel) triggerResult {
+	result := triggerResult{build: TriggeredBuildModel{AppSlug: target.AppSlug, WorkflowID: target.WorkflowID, Pipeline: target.PipelineID}}
+	if target.MatrixCell != nil {
+		result.build.MatrixIndex = target.MatrixCell.Index
+		result.build.Matrix = map[string]string{}
+		for _, environment := range target.MatrixCell.Environments {
+			result.build.Matrix[environment.MappedTo] = environment.Value
+		}
+	}
+
+	if configs.isSkipIfRunning() && target.WorkflowID != "" && target.MatrixCell == nil {
+		runningBuild, found, err := findRunningBuild(client, target.AppSlug, target.WorkflowID, configs.Branch, configs.CommitHash)
+		if err != nil {
+			result.err = categorize(errorCategoryNetwork, fmt.Errorf("could not list running builds, error: %w", err))
+			return result
+		}
+
+		if found {
+			logger.Warnf("Build #%d of workflow %s is already running on the same branch and commit, not triggering a new one", runningBuild.BuildNumber, target.WorkflowID)
+			result.build.BuildSlug = runningBuild.Slug
+			result.build.BuildNumber = runningBuild.BuildNumber
+			result.build.BuildURL = client.buildURL(runningBuild.Slug)
+			result.build.Reused = true
+			return result
+		}
 	}
 
-	fmt.Println()
-	log.Infof("Triggered build slug: %s", responseModel.BuildSlug)
-	log.Infof("Triggered build number: %d", responseModel.BuildNumber)
-	log.Infof("Triggered build URL: %s", responseModel.BuildURL)
-	log.Infof("Triggered workflow ID: %s", responseModel.TriggeredWorkflow)
+	requestBody, err := createRequestBodyFromConfigs(configs, target)
+	if err != nil {
+		result.err = newStepError(errorCategoryInternal, "could not create request body, error: %s", err)
+		return result
+	}
 
-	if err := exportEnvironmentWithEnvman(triggeredBuildSlug, responseModel.BuildSlug); err != nil {
-		log.Errorf("Could not export triggered build slug: %s", err)
-		os.Exit(5)
+	requestURL := client.triggerURL(target.AppSlug)
+	if configs.isRESTProtocol() {
+		requestURL = client.restTriggerURL(target.AppSlug)
 	}
 
-	if err := exportEnvironmentWithEnvman(triggeredBuildNumber, strconv.Itoa(responseModel.BuildNumber)); err != nil {
-		log.Errorf("Could not export triggered build number: %s", err)
-		os.Exit(5)
+	request, err := createRequest(requestURL, requestBody)
+	if err != nil {
+		result.err = newStepError(errorCategoryInternal, "could not create request, error: %s", err)
+		return result
+	}
+	if configs.isRESTProtocol() {
+		request.Header.Add("Authorization", client.accessToken)
+	}
+
+	responseModel, err := performRequest(client, request, configs.isRESTProtocol())
+	if err != nil {
+		result.err = categorize(errorCategoryNetwork, fmt.Errorf("could not send request, error: %w", err))
+		return result
 	}
 
-	if err := exportEnvironmentWithEnvman(triggeredBuildURL, responseModel.BuildURL); err != nil {
-		log.Errorf("Could not export triggered build URL: %s", err)
-		os.Exit(5)
+	logger.Infof("Build Trigger status: %s", responseModel.Status)
+
+	if responseModel.Message != "ok" {
+		result.err = newStepError(errorCategoryRejected, "build not triggered, status: %s", responseModel.Message)
+		return result
+	}
+
+	triggeredBuilds := []TriggeredBuildModel{}
+	for _, triggerResult := range responseModel.triggerResults() {
+		if triggerResult.Status != "" && triggerResult.Status != "ok" {
+			logger.Warnf("Build of workflow %s not triggered, status: %s, message: %s", triggerResult.TriggeredWorkflow, triggerResult.Status, triggerResult.Message)
+			continue
+		}
+
+		build := result.build
+		build.BuildSlug = triggerResult.BuildSlug
+		build.BuildNumber = triggerResult.BuildNumber
+		build.BuildURL = triggerResult.BuildURL
+		build.ResultIndex = len(triggeredBuilds)
+		if triggerResult.TriggeredWorkflow != "" && target.PipelineID == "" {
+			build.WorkflowID = triggerResult.TriggeredWorkflow
+		}
+		if target.PipelineID != "" {
+			// Older responses report the ID of the started pipeline as its build slug.
+			build.PipelineID = triggerResult.PipelineID
+			if build.PipelineID == "" {
+				build.PipelineID = triggerResult.BuildSlug
+			}
+			if build.BuildURL == "" {
+				build.BuildURL = client.pipelineURL(target.AppSlug, build.PipelineID)
+			}
+		}
+		triggeredBuilds = append(triggeredBuilds, build)
 	}
 
-	if err := exportEnvironmentWithEnvman(triggeredWorkflowID, responseModel.TriggeredWorkflow); err != nil {
-		log.Errorf("Could not export triggered workflow: %s", err)
-		os.Exit(5)
+	if len(triggeredBuilds) == 0 {
+		result.err = newStepError(errorCategoryRejected, "none of the %d build(s) was triggered", len(responseModel.triggerResults()))
+		return result
 	}
+	if len(triggeredBuilds) > 1 {
+		logger.Infof("Trigger request started %d builds", len(triggeredBuilds))
+	}
+
+	result.build, result.otherResults = triggeredBuilds[0], triggeredBuilds[1:]
+	return result
+}
+
+// triggerTargetName describes what a trigger request started, e.g. in error messages.
+func triggerTargetName(build TriggeredBuildModel) string {
+	if build.Pipeline != "" {
+		return "pipeline " + build.Pipeline
+	}
+	return "workflow " + build.WorkflowID
 }
 
-func createRequestBodyFromConfigs(configs ConfigsModel) ([]byte, error) {
+func createRequestBodyFromConfigs(configs ConfigsModel, target TriggerTargetModel) ([]byte, error) {
+	chain, err := configs.triggerChain()
+	if err != nil {
+		return nil, err
+	}
+
+	chainEnvironment, err := chain.toEnvironment()
+	if err != nil {
+		return nil, err
+	}
+
+	injectedEnvironments := []EnvironmentVariableModel{}
+	if target.MatrixCell != nil {
+		injectedEnvironments = append(injectedEnvironments, target.MatrixCell.Environments...)
+	}
+	injectedEnvironments = append(injectedEnvironments, chainEnvironment)
+
+	environments := []EnvironmentVariableModel{}
+	for _, environment := range createExportedEnvironment(configs.ExportedVariableNames) {
+		if !containsEnvironment(injectedEnvironments, environment.MappedTo) {
+			environments = append(environments, environment)
+		}
+	}
+	environments = append(environments, injectedEnvironments...)
+
+	commitPaths, err := parseCommitPaths(configs.CommitPaths)
+	if err != nil {
+		return nil, err
+	}
+
+	priority, err := configs.priority()
+	if err != nil {
+		return nil, err
+	}
+
+	hookInfo := HookInfoModel{
+		Type:     "bitrise",
+		APIToken: target.APIToken,
+	}
+	if configs.isRESTProtocol() {
+		// The REST API authenticates with the Authorization header instead.
+		hookInfo.APIToken = ""
+	}
+
 	requestModel := RequestModel{
-		HookInfo: HookInfoModel{
-			Type:     "bitrise",
-			APIToken: configs.APIToken,
-		},
+		HookInfo: hookInfo,
 		BuildParams: BuildParamsModel{
 			Branch:                   configs.Branch,
 			Tag:                      configs.Tag,
 			CommitHash:               configs.CommitHash,
 			CommitMessage:            configs.CommitMessage,
-			WorkflowID:               configs.WorkflowID,
+			WorkflowID:               target.WorkflowID,
+			PipelineID:               target.PipelineID,
 			BranchDest:               configs.BranchDest,
 			PullRequestID:            configs.PullRequestID,
 			PullRequestRepositoryURL: configs.PullRequestRepositoryURL,
 			PullRequestMergeBranch:   configs.PullRequestMergeBranch,
 			PullRequestHeadBranch:    configs.PullRequestHeadBranch,
-			Environments:             createExportedEnvironment(configs.ExportedVariableNames),
+			Environments:             environments,
 			BranchRepoOwner:          configs.BranchRepoOwner,
 			BranchDestRepoOwner:      configs.BranchDestRepoOwner,
+
+			SkipGitStatusReport:              configs.isSkipGitStatusReport(),
+			CommitPaths:                      commitPaths,
+			DiffURL:                          configs.DiffURL,
+			BaseRepositoryURL:                configs.BaseRepositoryURL,
+			HeadRepositoryURL:                configs.HeadRepositoryURL,
+			PullRequestAuthor:                configs.PullRequestAuthor,
+			PullRequestUnverifiedMergeBranch: configs.PullRequestUnverifiedMergeBranch,
+			StackID:                          configs.StackID,
+			MachineTypeID:                    configs.MachineTypeID,
+			Priority:                         priority,
 		},
 	}
 
 	return json.Marshal(requestModel)
 }
 
-func createRequest(appSlug string, body []byte) (*http.Request, error) {
-	requestURL := fmt.Sprintf("https://app.bitrise.io/app/%s/build/start.json", appSlug)
+func createRequest(requestURL string, body []byte) (*http.Request, error) {
 	request, err := http.NewRequest("POST", requestURL, bytes.NewBuffer(body))
 	if request != nil {
 		request.Header.Add("Content-Type", "application/json")
@@ -114,9 +486,8 @@ func createRequest(appSlug string, body []byte) (*http.Request, error) {
 	return request, err
 }
 
-func performRequest(request *http.Request) (ResponseModel, error) {
-	client := http.Client{}
-	response, err := client.Do(request)
+func performRequest(client apiClient, request *http.Request, isRESTProtocol bool) (ResponseModel, error) {
+	response, err := doWithRetry(client.httpClient, request, client.retryCount)
 	var responseModel ResponseModel
 
 	if err != nil {
@@ -126,16 +497,34 @@ func performRequest(request *http.Request) (ResponseModel, error) {
 	defer func() {
 		err := response.Body.Close()
 		if err != nil {
-			log.Warnf("Failed to close response body, error: %s", err)
+			logger.Warnf("Failed to close response body, error: %s", err)
 		}
 	}()
 
-	log.Infof("Build Trigger API HTTP response status: %s", response.Status)
+	logger.Event(logLevelInfo, "http_response", logFields{
+		"method":      request.Method,
+		"path":        request.URL.Path,
+		"status_code": response.StatusCode,
+	}, "Build Trigger API HTTP response status: %s", response.Status)
 	contents, err := ioutil.ReadAll(response.Body)
 	if err != nil {
 		return responseModel, err
 	}
 
-	err = json.Unmarshal(contents, &responseModel)
-	return responseModel, err
+	if response.StatusCode < 200 || response.StatusCode > 299 {
+		return responseModel, newResponseError(request, response, contents, client.secrets)
+	}
+
+	if isRESTProtocol {
+		var restResponseModel RESTResponseModel
+		if err := json.Unmarshal(contents, &restResponseModel); err != nil {
+			return responseModel, newNonJSONResponseError(request, response, contents, client.secrets, err)
+		}
+		return restResponseModel.toResponseModel(), nil
+	}
+
+	if err := json.Unmarshal(contents, &responseModel); err != nil {
+		return responseModel, newNonJSONResponseError(request, response, contents, client.secrets, err)
+	}
+	return responseModel, nil
 }
@@ -1,6 +1,9 @@
 package main
 
 import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
 	"github.com/stretchr/testify/require"
 	"testing"
 	"os"
@@ -91,3 +94,134 @@ func TestValidateConfigsValidVariables(t *testing.T) {
 	}
 	require.NoError(t, configs.validate())
 }
+
+func TestValidateConfigsDuplicatedWorkflowID(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		WorkflowID: "unit-tests|unit-tests",
+	}
+	require.EqualError(t, configs.validate(), "workflow ID specified more than once: unit-tests")
+}
+
+func TestWorkflowIDsEmpty(t *testing.T) {
+	configs := ConfigsModel{}
+	require.Equal(t, []string{""}, configs.workflowIDs())
+}
+
+func TestWorkflowIDsMultiple(t *testing.T) {
+	configs := ConfigsModel{WorkflowID: "unit-tests|ui-tests|lint"}
+	require.Equal(t, []string{"unit-tests", "ui-tests", "lint"}, configs.workflowIDs())
+}
+
+func TestCreateRequestBodyFromConfigsUsesWorkflowID(t *testing.T) {
+	configs := ConfigsModel{APIToken: "token", WorkflowID: "unit-tests|lint"}
+	body, err := createRequestBodyFromConfigs(configs, TriggerTargetModel{APIToken: "token", WorkflowID: "lint"})
+	require.NoError(t, err)
+
+	var requestModel RequestModel
+	require.NoError(t, json.Unmarshal(body, &requestModel))
+	require.Equal(t, "lint", requestModel.BuildParams.WorkflowID)
+}
+
+func TestValidateConfigsRESTProtocolWithoutAccessToken(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:    "token",
+		AppSlug:     "slug",
+		APIProtocol: "rest",
+	}
+	require.EqualError(t, configs.validate(), "empty Access token specified, it is required by the REST API protocol")
+}
+
+func TestValidateConfigsRESTProtocolWithoutAPIToken(t *testing.T) {
+	configs := ConfigsModel{
+		AccessToken: "access",
+		AppSlug:     "slug",
+		APIProtocol: "rest",
+	}
+	require.NoError(t, configs.validate())
+}
+
+func TestValidateConfigsInvalidProtocol(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:    "token",
+		AppSlug:     "slug",
+		APIProtocol: "graphql",
+	}
+	require.EqualError(t, configs.validate(), "invalid API protocol specified: graphql, allowed: legacy, rest")
+}
+
+func TestCreateRequestBodyFromConfigsRESTProtocolOmitsAPIToken(t *testing.T) {
+	configs := ConfigsModel{APIToken: "token", AccessToken: "access", APIProtocol: "rest"}
+	body, err := createRequestBodyFromConfigs(configs, TriggerTargetModel{APIToken: "token", WorkflowID: "lint"})
+	require.NoError(t, err)
+	require.NotContains(t, string(body), "api_token")
+}
+
+func TestRESTResponseModelToResponseModel(t *testing.T) {
+	var restResponseModel RESTResponseModel
+	require.NoError(t, json.Unmarshal([]byte(`{"status":"ok","message":"webhook processed","slug":"app","service":"bitrise","build_slug":"build","build_number":12,"build_url":"https://app.bitrise.io/build/build","triggered_workflow":"lint"}`), &restResponseModel))
+
+	responseModel := restResponseModel.toResponseModel()
+	require.Equal(t, "ok", responseModel.Message)
+	require.Equal(t, "webhook processed", responseModel.Status)
+	require.Equal(t, "build", responseModel.BuildSlug)
+	require.Equal(t, 12, responseModel.BuildNumber)
+	require.Equal(t, "lint", responseModel.TriggeredWorkflow)
+}
+
+func TestTriggerBuildMultipleResults(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"ok","message":"webhook processed","results":[
+			{"status":"ok","message":"webhook processed","build_slug":"first","build_number":1,"build_url":"https://app.bitrise.io/build/first","triggered_workflow":"build"},
+			{"status":"error","message":"workflow disabled","triggered_workflow":"disabled"},
+			{"status":"ok","message":"webhook processed","build_slug":"second","build_number":2,"build_url":"https://app.bitrise.io/build/second","triggered_workflow":"test"}
+		]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{AppSlug: "app", APIToken: "token", APIBaseURL: server.URL, RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	result := triggerBuild(client, configs, TriggerTargetModel{AppSlug: "app", APIToken: "token"})
+	require.NoError(t, result.err)
+	require.Equal(t, TriggeredBuildModel{AppSlug: "app", WorkflowID: "build", BuildSlug: "first", BuildNumber: 1, BuildURL: "https://app.bitrise.io/build/first"}, result.build)
+	require.Equal(t, []TriggeredBuildModel{
+		{AppSlug: "app", WorkflowID: "test", BuildSlug: "second", BuildNumber: 2, BuildURL: "https://app.bitrise.io/build/second", ResultIndex: 1},
+	}, result.otherResults)
+}
+
+func TestTriggerBuildNoSuccessfulResult(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"status":"ok","results":[{"status":"error","message":"workflow disabled"}]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{AppSlug: "app", APIToken: "token", APIBaseURL: server.URL, RetryCount: "0"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	result := triggerBuild(client, configs, TriggerTargetModel{AppSlug: "app", APIToken: "token"})
+	require.Equal(t, errorCategoryRejected, errorCategory(result.err))
+}
+
+func TestCollectTriggerResultsKeepsStartedBuilds(t *testing.T) {
+	builds, err := collectTriggerResults([]triggerResult{
+		{build: TriggeredBuildModel{AppSlug: "app", WorkflowID: "lint", BuildSlug: "lint-build"}},
+		{build: TriggeredBuildModel{AppSlug: "app", WorkflowID: "test"}, err: newStepError(errorCategoryRejected, "build not triggered")},
+		{build: TriggeredBuildModel{AppSlug: "app", WorkflowID: "ui", BuildSlug: "ui-build"}},
+	})
+	require.Error(t, err)
+	require.Equal(t, errorCategoryRejected, errorCategory(err))
+	require.Equal(t, []string{"lint-build", "ui-build"}, []string{builds[0].BuildSlug, builds[1].BuildSlug})
+
+	_, err = collectTriggerResults([]triggerResult{
+		{build: TriggeredBuildModel{AppSlug: "app", WorkflowID: "lint"}, err: newStepError(errorCategoryAuth, "unauthorized")},
+		{build: TriggeredBuildModel{AppSlug: "app", WorkflowID: "test"}, err: newStepError(errorCategoryRejected, "build not triggered")},
+	})
+	require.Contains(t, err.Error(), "2 of 2 trigger requests failed")
+	require.Equal(t, errorCategoryAuth, errorCategory(err))
+}
@@ -0,0 +1,177 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+const maxMatrixCells = 100
+
+// expandMatrix returns the cross-product of the matrix values without the excluded combinations, followed by the included ones.
+// The matrix is a list of `KEY=value1|value2` lines, includes and excludes are lists of `KEY=value,KEY2=value2` lines.
+// An exclude line removes every cell which matches all of its values.
+func expandMatrix(matrix, include, exclude string) ([]MatrixCellModel, error) {
+	dimensions, err := parseMatrixDimensions(matrix)
+	if err != nil {
+		return nil, err
+	}
+
+	excludes, err := parseMatrixCombinations(exclude)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matrix exclude: %s", err)
+	}
+
+	includes, err := parseMatrixCombinations(include)
+	if err != nil {
+		return nil, fmt.Errorf("invalid matrix include: %s", err)
+	}
+
+	if count, exact := countMatrixCells(dimensions, includes); !exact {
+		return nil, fmt.Errorf("matrix expands to more than %d cells, maximum is %d", maxMatrixCells, maxMatrixCells)
+	} else if count > maxMatrixCells {
+		return nil, fmt.Errorf("matrix expands to %d cells, maximum is %d", count, maxMatrixCells)
+	}
+
+	combinations := [][]EnvironmentVariableModel{}
+	if len(dimensions) > 0 {
+		combinations = [][]EnvironmentVariableModel{{}}
+	}
+	for _, dimension := range dimensions {
+		expanded := [][]EnvironmentVariableModel{}
+		for _, combination := range combinations {
+			for _, value := range dimension.values {
+				cell := append(append([]EnvironmentVariableModel{}, combination...), EnvironmentVariableModel{MappedTo: dimension.key, Value: value})
+				expanded = append(expanded, cell)
+			}
+		}
+		combinations = expanded
+	}
+
+	cells := []MatrixCellModel{}
+	for _, combination := range append(filterMatrixCombinations(combinations, excludes), includes...) {
+		cells = append(cells, MatrixCellModel{Index: len(cells), Environments: combination})
+	}
+	return cells, nil
+}
+
+// countMatrixCells returns the number of cells of the matrix before the excludes are applied.
+// The count stops growing once it passes maxMatrixCells, in which case exact is false.
+func countMatrixCells(dimensions []matrixDimension, includes [][]EnvironmentVariableModel) (count int, exact bool) {
+	product := 0
+	if len(dimensions) > 0 {
+		product = 1
+	}
+	for _, dimension := range dimensions {
+		product *= len(dimension.values)
+		if product > maxMatrixCells {
+			return product, false
+		}
+	}
+
+	return product + len(includes), true
+}
+
+type matrixDimension struct {
+	key    string
+	values []string
+}
+
+func parseMatrixDimensions(matrix string) ([]matrixDimension, error) {
+	dimensions := []matrixDimension{}
+	keys := map[string]bool{}
+
+	for _, line := range nonEmptyLines(matrix) {
+		key, value, err := splitKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid matrix line: %s", err)
+		}
+		if keys[key] {
+			return nil, fmt.Errorf("matrix key specified more than once: %s", key)
+		}
+		keys[key] = true
+
+		values := splitPipeSeparatedStringArray(value)
+		for _, value := range values {
+			if value == "" {
+				return nil, fmt.Errorf("empty matrix value specified for key: %s", key)
+			}
+		}
+		if len(values) == 0 {
+			return nil, fmt.Errorf("no matrix values specified for key: %s", key)
+		}
+
+		dimensions = append(dimensions, matrixDimension{key: key, values: values})
+	}
+
+	return dimensions, nil
+}
+
+func parseMatrixCombinations(input string) ([][]EnvironmentVariableModel, error) {
+	combinations := [][]EnvironmentVariableModel{}
+
+	for _, line := range nonEmptyLines(input) {
+		combination := []EnvironmentVariableModel{}
+		for _, pair := range strings.Split(line, ",") {
+			key, value, err := splitKeyValue(pair)
+			if err != nil {
+				return nil, err
+			}
+			combination = append(combination, EnvironmentVariableModel{MappedTo: key, Value: value})
+		}
+		combinations = append(combinations, combination)
+	}
+
+	return combinations, nil
+}
+
+func filterMatrixCombinations(combinations, excludes [][]EnvironmentVariableModel) [][]EnvironmentVariableModel {
+	filtered := [][]EnvironmentVariableModel{}
+	for _, combination := range combinations {
+		excluded := false
+		for _, exclude := range excludes {
+			if matchesMatrixCombination(combination, exclude) {
+				excluded = true
+				break
+			}
+		}
+		if !excluded {
+			filtered = append(filtered, combination)
+		}
+	}
+	return filtered
+}
+
+// matchesMatrixCombination reports whether the combination contains every value of the pattern.
+func matchesMatrixCombination(combination, pattern []EnvironmentVariableModel) bool {
+	for _, expected := range pattern {
+		found := false
+		for _, environment := range combination {
+			if environment.MappedTo == expected.MappedTo && environment.Value == expected.Value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}
+
+func splitKeyValue(input string) (string, string, error) {
+	parts := strings.SplitN(strings.TrimSpace(input), "=", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", fmt.Errorf("expected KEY=value, got: %s", input)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
+
+func nonEmptyLines(input string) []string {
+	lines := []string{}
+	for _, line := range strings.Split(input, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func cellValues(cells []MatrixCellModel) []string {
+	values := []string{}
+	for _, cell := range cells {
+		value := ""
+		for _, environment := range cell.Environments {
+			value += environment.MappedTo + "=" + environment.Value + ";"
+		}
+		values = append(values, value)
+	}
+	return values
+}
+
+func TestExpandMatrixCrossProduct(t *testing.T) {
+	cells, err := expandMatrix("API_LEVEL=28|30\nFLAVOR=free|paid\n", "", "")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"API_LEVEL=28;FLAVOR=free;",
+		"API_LEVEL=28;FLAVOR=paid;",
+		"API_LEVEL=30;FLAVOR=free;",
+		"API_LEVEL=30;FLAVOR=paid;",
+	}, cellValues(cells))
+	require.Equal(t, 3, cells[3].Index)
+}
+
+func TestExpandMatrixIncludeExclude(t *testing.T) {
+	cells, err := expandMatrix("API_LEVEL=28|30\nFLAVOR=free|paid", "API_LEVEL=33,FLAVOR=free", "API_LEVEL=28,FLAVOR=paid\nAPI_LEVEL=30")
+	require.NoError(t, err)
+	require.Equal(t, []string{
+		"API_LEVEL=28;FLAVOR=free;",
+		"API_LEVEL=33;FLAVOR=free;",
+	}, cellValues(cells))
+}
+
+func TestExpandMatrixEmpty(t *testing.T) {
+	cells, err := expandMatrix("", "", "")
+	require.NoError(t, err)
+	require.Equal(t, 0, len(cells))
+}
+
+func TestExpandMatrixInvalid(t *testing.T) {
+	for matrix, expectedError := range map[string]string{
+		"API_LEVEL":        "invalid matrix line: expected KEY=value, got: API_LEVEL",
+		"=28|30":           "invalid matrix line: expected KEY=value, got: =28|30",
+		"API_LEVEL=28||30": "empty matrix value specified for key: API_LEVEL",
+		"API_LEVEL=":       "no matrix values specified for key: API_LEVEL",
+		"A=1\nA=2":         "matrix key specified more than once: A",
+	} {
+		_, err := expandMatrix(matrix, "", "")
+		require.EqualError(t, err, expectedError, matrix)
+	}
+}
+
+func TestTriggerTargetsMatrix(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "app",
+		WorkflowID: "tests|lint",
+		Matrix:     "FLAVOR=free|paid",
+	}
+
+	targets, err := configs.triggerTargets()
+	require.NoError(t, err)
+	require.Equal(t, 4, len(targets))
+	require.Equal(t, "tests", targets[1].WorkflowID)
+	require.Equal(t, 1, targets[1].MatrixCell.Index)
+	require.Equal(t, "lint", targets[2].WorkflowID)
+	require.Equal(t, 0, targets[2].MatrixCell.Index)
+}
+
+func TestCreateRequestBodyFromConfigsInjectsMatrixCell(t *testing.T) {
+	cell := MatrixCellModel{Environments: []EnvironmentVariableModel{{MappedTo: "FLAVOR", Value: "paid"}}}
+	body, err := createRequestBodyFromConfigs(ConfigsModel{}, TriggerTargetModel{WorkflowID: "tests", MatrixCell: &cell})
+	require.NoError(t, err)
+
+	var requestModel RequestModel
+	require.NoError(t, json.Unmarshal(body, &requestModel))
+	require.Equal(t, "FLAVOR", requestModel.BuildParams.Environments[0].MappedTo)
+	require.Equal(t, "paid", requestModel.BuildParams.Environments[0].Value)
+}
+
+func TestMatrixCellOutputs(t *testing.T) {
+	builds := []TriggeredBuildModel{
+		{WorkflowID: "tests", MatrixIndex: 0, Matrix: map[string]string{"FLAVOR": "free"}},
+		{WorkflowID: "tests", MatrixIndex: 1, Matrix: map[string]string{"FLAVOR": "paid"}},
+		{WorkflowID: "lint", MatrixIndex: 0, Matrix: map[string]string{"FLAVOR": "free"}},
+	}
+
+	cells := matrixCellOutputs(builds)
+	require.Equal(t, 2, len(cells))
+	require.Equal(t, "free", cells[0].Environments["FLAVOR"])
+	require.Equal(t, 2, len(cells[0].Builds))
+	require.Equal(t, 1, len(cells[1].Builds))
+}
+
+func TestExpandMatrixTooManyCells(t *testing.T) {
+	_, err := expandMatrix("A=1|2|3|4|5|6|7|8|9|10\nB=1|2|3|4|5|6|7|8|9|10", "A=11,B=11", "")
+	require.EqualError(t, err, "matrix expands to 101 cells, maximum is 100")
+
+	matrix := "A=1|2|3|4|5\n"
+	for _, key := range []string{"B", "C", "D", "E", "F", "G", "H", "I", "J", "K", "L"} {
+		matrix += key + "=1|2|3|4|5\n"
+	}
+	_, err = expandMatrix(matrix, "", "")
+	require.EqualError(t, err, "matrix expands to more than 100 cells, maximum is 100")
+}
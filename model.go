@@ -17,6 +17,89 @@ type ConfigsModel struct {
 	ExportedVariableNames    string
 	BranchRepoOwner          string
 	BranchDestRepoOwner      string
+	WaitForBuild             string
+	AccessToken              string
+	PollInterval             string
+	RetryCount               string
+	APIBaseURL               string
+	APIProtocol              string
+	AbortReason              string
+	DownloadArtifacts        string
+	ArtifactPatterns         string
+	ArtifactsDir             string
+	BuildLogMode             string
+	BuildLogLines            string
+	ResultPolicy             string
+	FailFast                 string
+	MaxTriggerDepth          string
+	TriggerChain             string
+	CurrentWorkflowID        string
+	CurrentAppSlug           string
+	SkipIfRunning            string
+	CancelSupersededBuilds   string
+	SupersededSkipTags       string
+	SupersededSkipPRs        string
+	AdditionalApps           string
+	Matrix                   string
+	MatrixInclude            string
+	MatrixExclude            string
+	PathRules                string
+	ChangesBaseRef           string
+	BitriseYMLPath           string
+	TriggerMapResolution     string
+	ValidateWorkflows        string
+	LogFormat                string
+	ConnectTimeout           string
+	ResponseTimeout          string
+	CABundlePath             string
+	WaitForAllResults        string
+	PipelineID               string
+
+	SkipGitStatusReport              string
+	CommitPaths                      string
+	DiffURL                          string
+	BaseRepositoryURL                string
+	HeadRepositoryURL                string
+	PullRequestAuthor                string
+	PullRequestUnverifiedMergeBranch string
+	StackID                          string
+	MachineTypeID                    string
+	Priority                         string
+}
+
+// AdditionalAppModel ...
+type AdditionalAppModel struct {
+	AppSlug     string
+	TokenEnvVar string
+	WorkflowIDs []string
+}
+
+// TriggerTargetModel ...
+type TriggerTargetModel struct {
+	AppSlug    string
+	APIToken   string
+	WorkflowID string
+	PipelineID string
+	MatrixCell *MatrixCellModel
+}
+
+// PathRuleModel ...
+type PathRuleModel struct {
+	Pattern     string
+	WorkflowIDs []string
+}
+
+// MatrixCellModel ...
+type MatrixCellModel struct {
+	Index        int
+	Environments []EnvironmentVariableModel
+}
+
+// MatrixCellOutputModel ...
+type MatrixCellOutputModel struct {
+	Index        int                   `json:"index"`
+	Environments map[string]string     `json:"environments"`
+	Builds       []TriggeredBuildModel `json:"builds"`
 }
 
 // RequestModel ...
@@ -28,7 +111,7 @@ type RequestModel struct {
 // HookInfoModel ...
 type HookInfoModel struct {
 	Type     string `json:"type"`
-	APIToken string `json:"api_token"`
+	APIToken string `json:"api_token,omitempty"`
 }
 
 // BuildParamsModel ...
@@ -38,6 +121,7 @@ type BuildParamsModel struct {
 	CommitHash               string                     `json:"commit_hash"`
 	CommitMessage            string                     `json:"commit_message"`
 	WorkflowID               string                     `json:"workflow_id"`
+	PipelineID               string                     `json:"pipeline_id,omitempty"`
 	BranchDest               string                     `json:"branch_dest"`
 	PullRequestID            string                     `json:"pull_request_id"`
 	PullRequestRepositoryURL string                     `json:"pull_request_repository_url"`
@@ -46,6 +130,24 @@ type BuildParamsModel struct {
 	Environments             []EnvironmentVariableModel `json:"environments"`
 	BranchDestRepoOwner      string                     `json:"branch_dest_repo_owner"`
 	BranchRepoOwner          string                     `json:"branch_repo_owner"`
+
+	SkipGitStatusReport              bool               `json:"skip_git_status_report,omitempty"`
+	CommitPaths                      []CommitPathsModel `json:"commit_paths,omitempty"`
+	DiffURL                          string             `json:"diff_url,omitempty"`
+	BaseRepositoryURL                string             `json:"base_repository_url,omitempty"`
+	HeadRepositoryURL                string             `json:"head_repository_url,omitempty"`
+	PullRequestAuthor                string             `json:"pull_request_author,omitempty"`
+	PullRequestUnverifiedMergeBranch string             `json:"pull_request_unverified_merge_branch,omitempty"`
+	StackID                          string             `json:"stack_id,omitempty"`
+	MachineTypeID                    string             `json:"machine_type_id,omitempty"`
+	Priority                         int                `json:"priority,omitempty"`
+}
+
+// CommitPathsModel ...
+type CommitPathsModel struct {
+	Added    []string `json:"added,omitempty"`
+	Removed  []string `json:"removed,omitempty"`
+	Modified []string `json:"modified,omitempty"`
 }
 
 // EnvironmentVariableModel ...
@@ -57,10 +159,186 @@ type EnvironmentVariableModel struct {
 
 // ResponseModel ...
 type ResponseModel struct {
-	Status            string `json:"message"`
-	Message           string `json:"status"`
+	Status            string               `json:"message"`
+	Message           string               `json:"status"`
+	BuildSlug         string               `json:"build_slug"`
+	BuildNumber       int                  `json:"build_number"`
+	BuildURL          string               `json:"build_url"`
+	TriggeredWorkflow string               `json:"triggered_workflow"`
+	PipelineID        string               `json:"pipeline_id"`
+	Results           []TriggerResultModel `json:"results"`
+}
+
+// TriggerResultModel is a build started by a trigger request which started multiple builds,
+// e.g. a pipeline or multiple matching trigger map items.
+type TriggerResultModel struct {
+	Status            string `json:"status"`
+	Message           string `json:"message"`
 	BuildSlug         string `json:"build_slug"`
 	BuildNumber       int    `json:"build_number"`
 	BuildURL          string `json:"build_url"`
 	TriggeredWorkflow string `json:"triggered_workflow"`
+	PipelineID        string `json:"pipeline_id"`
+}
+
+// RESTResponseModel ...
+type RESTResponseModel struct {
+	Status            string               `json:"status"`
+	Message           string               `json:"message"`
+	Service           string               `json:"service"`
+	AppSlug           string               `json:"slug"`
+	BuildSlug         string               `json:"build_slug"`
+	BuildNumber       int                  `json:"build_number"`
+	BuildURL          string               `json:"build_url"`
+	TriggeredWorkflow string               `json:"triggered_workflow"`
+	PipelineID        string               `json:"pipeline_id"`
+	Results           []TriggerResultModel `json:"results"`
+}
+
+// toResponseModel converts the REST API response to the legacy build trigger API response.
+// Note that ResponseModel maps the "status" and "message" JSON fields the other way around.
+func (model RESTResponseModel) toResponseModel() ResponseModel {
+	return ResponseModel{
+		Status:            model.Message,
+		Message:           model.Status,
+		BuildSlug:         model.BuildSlug,
+		BuildNumber:       model.BuildNumber,
+		BuildURL:          model.BuildURL,
+		TriggeredWorkflow: model.TriggeredWorkflow,
+		PipelineID:        model.PipelineID,
+		Results:           model.Results,
+	}
+}
+
+// triggerResults returns the builds started by the trigger request. A response without a results list describes a single build.
+func (model ResponseModel) triggerResults() []TriggerResultModel {
+	if len(model.Results) > 0 {
+		return model.Results
+	}
+	return []TriggerResultModel{{
+		Status:            model.Message,
+		Message:           model.Status,
+		BuildSlug:         model.BuildSlug,
+		BuildNumber:       model.BuildNumber,
+		BuildURL:          model.BuildURL,
+		TriggeredWorkflow: model.TriggeredWorkflow,
+		PipelineID:        model.PipelineID,
+	}}
+}
+
+// BuildStatusResponseModel ...
+type BuildStatusResponseModel struct {
+	Data BuildModel `json:"data"`
+}
+
+// BuildListResponseModel ...
+type BuildListResponseModel struct {
+	Data   []BuildModel `json:"data"`
+	Paging PagingModel  `json:"paging"`
+}
+
+// BuildModel ...
+type BuildModel struct {
+	Slug              string `json:"slug"`
+	Status            int    `json:"status"`
+	StatusText        string `json:"status_text"`
+	BuildNumber       int    `json:"build_number"`
+	TriggeredWorkflow string `json:"triggered_workflow"`
+	Branch            string `json:"branch"`
+	CommitHash        string `json:"commit_hash"`
+	Tag               string `json:"tag"`
+	PullRequestID     int    `json:"pull_request_id"`
+	IsOnHold          bool   `json:"is_on_hold"`
+}
+
+// TriggeredBuildModel ...
+type TriggeredBuildModel struct {
+	AppSlug     string `json:"app_slug"`
+	WorkflowID  string `json:"workflow_id"`
+	BuildSlug   string `json:"build_slug"`
+	BuildNumber int    `json:"build_number"`
+	BuildURL    string `json:"build_url"`
+	Status      string `json:"status,omitempty"`
+	Reused      bool   `json:"reused,omitempty"`
+
+	Matrix      map[string]string `json:"matrix,omitempty"`
+	MatrixIndex int               `json:"-"`
+
+	// ResultIndex is the position of the build in the results of its trigger request.
+	ResultIndex int `json:"result_index,omitempty"`
+
+	Pipeline   string               `json:"pipeline,omitempty"`
+	PipelineID string               `json:"pipeline_id,omitempty"`
+	Stages     []PipelineStageModel `json:"stages,omitempty"`
+}
+
+// PipelineModel ...
+type PipelineModel struct {
+	ID     string               `json:"id"`
+	Name   string               `json:"name"`
+	Status string               `json:"status"`
+	Stages []PipelineStageModel `json:"stages"`
+}
+
+// PipelineStageModel ...
+type PipelineStageModel struct {
+	Name      string                  `json:"name"`
+	Status    string                  `json:"status"`
+	Workflows []PipelineWorkflowModel `json:"workflows"`
+}
+
+// PipelineWorkflowModel ...
+type PipelineWorkflowModel struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Status string `json:"status"`
+}
+
+// AbortRequestModel ...
+type AbortRequestModel struct {
+	AbortReason       string `json:"abort_reason"`
+	AbortWithSuccess  bool   `json:"abort_with_success"`
+	SkipNotifications bool   `json:"skip_notifications"`
+}
+
+// ArtifactListResponseModel ...
+type ArtifactListResponseModel struct {
+	Data   []ArtifactModel `json:"data"`
+	Paging PagingModel     `json:"paging"`
+}
+
+// ArtifactResponseModel ...
+type ArtifactResponseModel struct {
+	Data ArtifactModel `json:"data"`
+}
+
+// ArtifactModel ...
+type ArtifactModel struct {
+	Slug                string `json:"slug"`
+	Title               string `json:"title"`
+	ArtifactType        string `json:"artifact_type"`
+	FileSizeBytes       int64  `json:"file_size_bytes"`
+	ExpiringDownloadURL string `json:"expiring_download_url"`
+}
+
+// PagingModel ...
+type PagingModel struct {
+	TotalItemCount int    `json:"total_item_count"`
+	PageItemLimit  int    `json:"page_item_limit"`
+	Next           string `json:"next"`
+}
+
+// BuildLogResponseModel ...
+type BuildLogResponseModel struct {
+	LogChunks           []LogChunkModel `json:"log_chunks"`
+	NextAfterTimestamp  string          `json:"next_after_timestamp"`
+	NextBeforeTimestamp string          `json:"next_before_timestamp"`
+	IsArchived          bool            `json:"is_archived"`
+	ExpiringRawLogURL   string          `json:"expiring_raw_log_url"`
+}
+
+// LogChunkModel ...
+type LogChunkModel struct {
+	Chunk    string `json:"chunk"`
+	Position int    `json:"position"`
 }
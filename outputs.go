@@ -0,0 +1,158 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var nonOutputKeyCharacters = regexp.MustCompile("[^A-Z0-9]+")
+
+// exportTriggeredBuilds exports the legacy single build outputs pointing at the first build,
+// per workflow outputs suffixed with the workflow ID and the JSON list of every triggered build.
+// If the builds belong to multiple apps, the per workflow outputs are suffixed with the app slug too
+// and a JSON list of the triggered builds is exported for each app.
+func exportTriggeredBuilds(builds []TriggeredBuildModel) error {
+	if len(builds) == 0 {
+		return nil
+	}
+
+	if err := exportTriggeredBuild(builds[0], ""); err != nil {
+		return err
+	}
+
+	buildsByApp := map[string][]TriggeredBuildModel{}
+	appSlugs := []string{}
+	for _, build := range builds {
+		if _, ok := buildsByApp[build.AppSlug]; !ok {
+			appSlugs = append(appSlugs, build.AppSlug)
+		}
+		buildsByApp[build.AppSlug] = append(buildsByApp[build.AppSlug], build)
+	}
+	isMultiApp := len(appSlugs) > 1
+
+	for i, keySuffix := range buildOutputKeySuffixes(builds, isMultiApp) {
+		if keySuffix == "" {
+			continue
+		}
+		if err := exportTriggeredBuild(builds[i], keySuffix); err != nil {
+			return err
+		}
+	}
+
+	if isMultiApp {
+		for _, appSlug := range appSlugs {
+			if err := exportJSON(triggeredBuilds+"_"+outputKeySuffix(appSlug), buildsByApp[appSlug]); err != nil {
+				return err
+			}
+		}
+	}
+
+	if cells := matrixCellOutputs(builds); len(cells) > 0 {
+		if err := exportJSON(triggeredBuildsMatrix, cells); err != nil {
+			return err
+		}
+	}
+
+	return exportJSON(triggeredBuilds, builds)
+}
+
+// buildOutputKeySuffixes returns the suffix of the per workflow outputs of each build, or an empty string for builds without a workflow.
+// The workflow IDs returned by the server may map to the same suffix (e.g. the same workflow started twice, or `ui-tests` and `ui_tests`),
+// in that case the later builds are suffixed with their position in builds too, so they do not overwrite each other's outputs.
+func buildOutputKeySuffixes(builds []TriggeredBuildModel, isMultiApp bool) []string {
+	keySuffixes := make([]string, len(builds))
+	used := map[string]bool{}
+	for i, build := range builds {
+		if build.WorkflowID == "" {
+			continue
+		}
+
+		keySuffix := "_" + outputKeySuffix(build.WorkflowID)
+		if isMultiApp {
+			keySuffix = "_" + outputKeySuffix(build.AppSlug) + keySuffix
+		}
+		if build.Matrix != nil {
+			keySuffix += "_" + strconv.Itoa(build.MatrixIndex)
+		}
+		if used[keySuffix] {
+			logger.Warnf("Outputs of build %s (%s) would overwrite the outputs of another build, exporting them with the %s suffix", build.BuildSlug, build.WorkflowID, keySuffix+"_"+strconv.Itoa(i))
+			keySuffix += "_" + strconv.Itoa(i)
+		}
+		used[keySuffix] = true
+		keySuffixes[i] = keySuffix
+	}
+	return keySuffixes
+}
+
+// matrixCellOutputs groups the builds by their matrix cells, ordered by the cell index.
+func matrixCellOutputs(builds []TriggeredBuildModel) []MatrixCellOutputModel {
+	cells := []MatrixCellOutputModel{}
+	for _, build := range builds {
+		if build.Matrix == nil {
+			continue
+		}
+
+		for len(cells) <= build.MatrixIndex {
+			cells = append(cells, MatrixCellOutputModel{Index: len(cells), Builds: []TriggeredBuildModel{}})
+		}
+		cells[build.MatrixIndex].Environments = build.Matrix
+		cells[build.MatrixIndex].Builds = append(cells[build.MatrixIndex].Builds, build)
+	}
+	return cells
+}
+
+func exportJSON(key string, value interface{}) error {
+	valueJSON, err := json.Marshal(value)
+	if err != nil {
+		return err
+	}
+	return exportEnvironmentWithEnvman(key, string(valueJSON))
+}
+
+func exportTriggeredBuild(build TriggeredBuildModel, keySuffix string) error {
+	outputs := map[string]string{
+		triggeredBuildSlug:   build.BuildSlug,
+		triggeredBuildNumber: strconv.Itoa(build.BuildNumber),
+		triggeredBuildURL:    build.BuildURL,
+	}
+	if keySuffix == "" {
+		outputs[triggeredWorkflowID] = build.WorkflowID
+	}
+	if build.Status != "" {
+		outputs[triggeredBuildStatus] = build.Status
+	}
+	if build.PipelineID != "" {
+		outputs[triggeredPipelineID] = build.PipelineID
+		outputs[triggeredPipelineURL] = build.BuildURL
+	}
+
+	for key, value := range outputs {
+		if err := exportEnvironmentWithEnvman(key+keySuffix, value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// buildDisplayName identifies the build in the log by its workflow ID (or pipeline) and matrix cell index.
+func buildDisplayName(build TriggeredBuildModel) string {
+	name := build.WorkflowID
+	if build.Pipeline != "" {
+		name = "pipeline " + build.Pipeline
+	}
+	if name == "" {
+		name = build.BuildSlug
+	}
+	if build.Matrix != nil {
+		name += fmt.Sprintf(" #%d", build.MatrixIndex)
+	}
+	return name
+}
+
+// outputKeySuffix converts an identifier (e.g. a workflow ID) to a form usable in an environment variable name.
+func outputKeySuffix(identifier string) string {
+	return strings.Trim(nonOutputKeyCharacters.ReplaceAllString(strings.ToUpper(identifier), "_"), "_")
+}
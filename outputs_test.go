@@ -0,0 +1,37 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOutputKeySuffix(t *testing.T) {
+	require.Equal(t, "UI_TESTS", outputKeySuffix("ui-tests"))
+	require.Equal(t, "DEPLOY_TO_STORE", outputKeySuffix("_deploy.to store"))
+}
+
+func TestValidateConfigsOutputKeySuffixCollision(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		WorkflowID: "ui-tests|ui_tests",
+	}
+	err := configs.validate()
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "UI_TESTS")
+
+	configs.WorkflowID = "ui-tests|lint"
+	require.NoError(t, configs.validate())
+}
+
+func TestBuildOutputKeySuffixesCollision(t *testing.T) {
+	builds := []TriggeredBuildModel{
+		{AppSlug: "app", WorkflowID: "ui-tests", BuildSlug: "first"},
+		{AppSlug: "app", WorkflowID: "ui_tests", BuildSlug: "second", ResultIndex: 1},
+		{AppSlug: "app", WorkflowID: "ui-tests", BuildSlug: "third", ResultIndex: 2},
+		{AppSlug: "app", BuildSlug: "pipeline", PipelineID: "pipeline"},
+		{AppSlug: "app", WorkflowID: "lint", BuildSlug: "lint"},
+	}
+	require.Equal(t, []string{"_UI_TESTS", "_UI_TESTS_1", "_UI_TESTS_2", "", "_LINT"}, buildOutputKeySuffixes(builds, false))
+}
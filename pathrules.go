@@ -0,0 +1,133 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/bitrise-io/go-utils/command"
+)
+
+// parsePathRules parses `<glob>=<workflow_id>,<workflow_id>...` lines.
+func parsePathRules(input string) ([]PathRuleModel, error) {
+	rules := []PathRuleModel{}
+
+	for _, line := range nonEmptyLines(input) {
+		pattern, workflows, err := splitKeyValue(line)
+		if err != nil {
+			return nil, fmt.Errorf("invalid path rule: %s", err)
+		}
+		if _, err := path.Match(strings.Replace(pattern, "**", "*", -1), ""); err != nil {
+			return nil, fmt.Errorf("invalid path rule pattern: %s", pattern)
+		}
+
+		rule := PathRuleModel{Pattern: pattern, WorkflowIDs: []string{}}
+		for _, workflowID := range strings.Split(workflows, ",") {
+			if workflowID = strings.TrimSpace(workflowID); workflowID == "" {
+				return nil, fmt.Errorf("empty workflow ID specified in path rule: %s", line)
+			}
+			rule.WorkflowIDs = append(rule.WorkflowIDs, workflowID)
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+func selectWorkflowsByPathRules(configs ConfigsModel) ([]string, error) {
+	rules, err := parsePathRules(configs.PathRules)
+	if err != nil {
+		return nil, err
+	}
+
+	files, err := changedFiles(configs.changesBaseRef(), configs.CommitHash)
+	if err != nil {
+		return nil, err
+	}
+	logger.Printf("%d file(s) changed", len(files))
+
+	return selectWorkflowsByChanges(splitPipeSeparatedStringArray(configs.WorkflowID), rules, files), nil
+}
+
+// selectWorkflowsByChanges returns the workflows to trigger for the changed files.
+// Workflows not mentioned by any rule are always selected, the others only if one of their rules matches a changed file.
+func selectWorkflowsByChanges(workflowIDs []string, rules []PathRuleModel, changedFiles []string) []string {
+	ruleWorkflows := map[string]bool{}
+	matchedWorkflows := map[string]bool{}
+	for _, rule := range rules {
+		for _, workflowID := range rule.WorkflowIDs {
+			ruleWorkflows[workflowID] = true
+		}
+
+		for _, file := range changedFiles {
+			if matchPathGlob(rule.Pattern, file) {
+				logger.Printf("Rule %s matched %s, selecting workflow(s): %s", rule.Pattern, file, strings.Join(rule.WorkflowIDs, ", "))
+				for _, workflowID := range rule.WorkflowIDs {
+					matchedWorkflows[workflowID] = true
+				}
+				break
+			}
+		}
+	}
+
+	selected := []string{}
+	added := map[string]bool{}
+	for _, workflowID := range workflowIDs {
+		if !ruleWorkflows[workflowID] || matchedWorkflows[workflowID] {
+			selected = append(selected, workflowID)
+			added[workflowID] = true
+		}
+	}
+	for _, rule := range rules {
+		for _, workflowID := range rule.WorkflowIDs {
+			if matchedWorkflows[workflowID] && !added[workflowID] {
+				selected = append(selected, workflowID)
+				added[workflowID] = true
+			}
+		}
+	}
+	return selected
+}
+
+// changedFiles lists the files changed between the merge base of baseRef and commit, and commit, using the local git repository.
+func changedFiles(baseRef, commit string) ([]string, error) {
+	if commit == "" {
+		commit = "HEAD"
+	}
+
+	cmd := command.New("git", "diff", "--name-only", baseRef+"..."+commit)
+	logger.Printf("$ %s", cmd.PrintableCommandArgs())
+	output, err := cmd.RunAndReturnTrimmedCombinedOutput()
+	if err != nil {
+		return nil, fmt.Errorf("%s, output: %s", err, output)
+	}
+
+	return nonEmptyLines(output), nil
+}
+
+// matchPathGlob matches a slash separated path against a glob pattern, where `**` matches any number of directories.
+func matchPathGlob(pattern, name string) bool {
+	return matchPathSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchPathSegments(patterns, names []string) bool {
+	for len(patterns) > 0 {
+		if patterns[0] == "**" {
+			for i := 0; i <= len(names); i++ {
+				if matchPathSegments(patterns[1:], names[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+
+		if len(names) == 0 {
+			return false
+		}
+		if matched, err := path.Match(patterns[0], names[0]); err != nil || !matched {
+			return false
+		}
+		patterns, names = patterns[1:], names[1:]
+	}
+	return len(names) == 0
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMatchPathGlob(t *testing.T) {
+	require.True(t, matchPathGlob("server/**", "server/main.go"))
+	require.True(t, matchPathGlob("server/**", "server/api/handlers/user.go"))
+	require.True(t, matchPathGlob("**/*.kt", "android/app/src/Main.kt"))
+	require.True(t, matchPathGlob("**/*.kt", "Main.kt"))
+	require.True(t, matchPathGlob("docs/*.md", "docs/README.md"))
+	require.False(t, matchPathGlob("docs/*.md", "docs/api/README.md"))
+	require.False(t, matchPathGlob("server/**", "client/server/main.go"))
+}
+
+func TestParsePathRules(t *testing.T) {
+	rules, err := parsePathRules("server/**=backend\n\nandroid/**=android-tests, lint\n")
+	require.NoError(t, err)
+	require.Equal(t, []PathRuleModel{
+		{Pattern: "server/**", WorkflowIDs: []string{"backend"}},
+		{Pattern: "android/**", WorkflowIDs: []string{"android-tests", "lint"}},
+	}, rules)
+}
+
+func TestParsePathRulesInvalid(t *testing.T) {
+	for _, input := range []string{"server/**", "=backend", "server/**=", "server/[=backend"} {
+		_, err := parsePathRules(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestSelectWorkflowsByChanges(t *testing.T) {
+	rules := []PathRuleModel{
+		{Pattern: "server/**", WorkflowIDs: []string{"backend"}},
+		{Pattern: "android/**", WorkflowIDs: []string{"android-tests", "lint"}},
+	}
+
+	selected := selectWorkflowsByChanges([]string{"always", "lint"}, rules, []string{"server/main.go", "README.md"})
+	require.Equal(t, []string{"always", "backend"}, selected)
+
+	selected = selectWorkflowsByChanges([]string{}, rules, []string{"android/app/build.gradle"})
+	require.Equal(t, []string{"android-tests", "lint"}, selected)
+
+	selected = selectWorkflowsByChanges([]string{}, rules, []string{"README.md"})
+	require.Equal(t, []string{}, selected)
+}
+
+func TestValidateConfigsPathRulesWithoutBaseRef(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:  "token",
+		AppSlug:   "slug",
+		PathRules: "server/**=backend",
+	}
+	require.EqualError(t, configs.validate(), "empty changes base ref and Pull Request destination branch specified, one of them is required by the path rules")
+
+	configs.BranchDest = "master"
+	require.NoError(t, configs.validate())
+	require.Equal(t, "origin/master", configs.changesBaseRef())
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const (
+	pipelineStatusSucceeded          = "succeeded"
+	pipelineStatusSucceededWithAbort = "succeeded_with_abort"
+	pipelineStatusFailed             = "failed"
+	pipelineStatusAborted            = "aborted"
+	pipelineStatusRunning            = "running"
+	pipelineStatusPending            = "pending"
+)
+
+// waitForPipeline polls the pipeline until it finishes and returns its final status and stages.
+// Status changes of the stages and their workflows are logged as they are noticed.
+func waitForPipeline(ctx context.Context, client apiClient, appSlug, pipelineID string, pollInterval time.Duration) (string, []PipelineStageModel, error) {
+	lastStatuses := map[string]string{}
+	for {
+		pipeline, err := fetchPipeline(ctx, client, appSlug, pipelineID)
+		if err != nil {
+			return "", nil, err
+		}
+
+		logPipelineChanges(pipelineID, pipeline.Stages, lastStatuses)
+
+		if status, finished := pipelineStatus(pipeline.Status); finished {
+			return status, pipeline.Stages, nil
+		}
+
+		logger.Event(logLevelNormal, "pipeline_status", logFields{
+			"app_slug":      appSlug,
+			"pipeline_id":   pipelineID,
+			"status":        pipelineStatusRunning,
+			"poll_interval": pollInterval.Seconds(),
+		}, "Pipeline %s is still running, checking again in %s", pipelineID, pollInterval)
+		select {
+		case <-ctx.Done():
+			return "", nil, ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+// logPipelineChanges logs the stages and workflows whose status differs from the one in lastStatuses, then records the new statuses.
+func logPipelineChanges(pipelineID string, stages []PipelineStageModel, lastStatuses map[string]string) {
+	for _, stage := range stages {
+		if status := stageStatus(stage.Status); lastStatuses[stage.Name] != status {
+			lastStatuses[stage.Name] = status
+			logger.Event(logLevelInfo, "pipeline_stage", logFields{
+				"pipeline_id": pipelineID,
+				"stage":       stage.Name,
+				"status":      status,
+			}, "Pipeline %s stage %s: %s", pipelineID, stage.Name, status)
+		}
+
+		for _, workflow := range stage.Workflows {
+			key := stage.Name + "/" + workflow.Name
+			if status := stageStatus(workflow.Status); lastStatuses[key] != status {
+				lastStatuses[key] = status
+				logger.Event(logLevelInfo, "pipeline_workflow", logFields{
+					"pipeline_id": pipelineID,
+					"stage":       stage.Name,
+					"workflow":    workflow.Name,
+					"workflow_id": workflow.ID,
+					"status":      status,
+				}, "Pipeline %s stage %s workflow %s: %s", pipelineID, stage.Name, workflow.Name, status)
+			}
+		}
+	}
+}
+
+func stageStatus(status string) string {
+	if status == "" {
+		return pipelineStatusPending
+	}
+	return status
+}
+
+func fetchPipeline(ctx context.Context, client apiClient, appSlug, pipelineID string) (PipelineModel, error) {
+	var responseModel PipelineModel
+	err := client.sendRESTRequest(ctx, "GET", fmt.Sprintf("/v0.1/apps/%s/pipelines/%s", appSlug, pipelineID), nil, &responseModel)
+	return responseModel, err
+}
+
+func abortPipeline(client apiClient, appSlug, pipelineID, reason string) error {
+	requestModel := AbortRequestModel{
+		AbortReason:       reason,
+		AbortWithSuccess:  false,
+		SkipNotifications: true,
+	}
+	return client.sendRESTRequest(context.Background(), "POST", fmt.Sprintf("/v0.1/apps/%s/pipelines/%s/abort", appSlug, pipelineID), requestModel, nil)
+}
+
+// pipelineStatus maps the status of a pipeline to the step's status names.
+// The second return value is false while the pipeline is still running.
+func pipelineStatus(status string) (string, bool) {
+	switch status {
+	case pipelineStatusSucceeded:
+		return buildStatusSuccess, true
+	case pipelineStatusSucceededWithAbort:
+		return buildStatusAbortedWithSuccess, true
+	case pipelineStatusFailed:
+		return buildStatusFailed, true
+	case pipelineStatusAborted:
+		return buildStatusAborted, true
+	default:
+		return "", false
+	}
+}
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsPipelineAndWorkflow(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		WorkflowID: "primary",
+		PipelineID: "release",
+	}
+	require.EqualError(t, configs.validate(), "both workflow ID and pipeline ID specified, only one of them can be triggered")
+
+	configs.WorkflowID = ""
+	require.NoError(t, configs.validate())
+}
+
+func TestValidateConfigsPipelineWithWorkflowOnlyInputs(t *testing.T) {
+	for expectedError, configs := range map[string]ConfigsModel{
+		"multiple pipeline IDs specified":                   {PipelineID: "release|deploy"},
+		"additional apps specified, it can only be used":    {PipelineID: "release", AdditionalApps: "other:OTHER_TOKEN"},
+		"matrix specified, it can only be used":             {PipelineID: "release", Matrix: "OS=ios,android"},
+		"path rules specified, it can only be used":         {PipelineID: "release", PathRules: "src/**=lint", ChangesBaseRef: "main"},
+		"download artifacts specified, it can only be used": {PipelineID: "release", DownloadArtifacts: "yes"},
+	} {
+		configs.APIToken = "token"
+		configs.AppSlug = "slug"
+		err := configs.validate()
+		require.Error(t, err, expectedError)
+		require.Contains(t, err.Error(), expectedError)
+	}
+
+	configs := ConfigsModel{APIToken: "token", AppSlug: "slug", PathRules: "src/**=lint", ChangesBaseRef: "main"}
+	require.NoError(t, configs.validate())
+}
+
+func TestTriggerPipeline(t *testing.T) {
+	var requestModel RequestModel
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&requestModel))
+		_, err := w.Write([]byte(`{"status":"ok","message":"webhook processed","build_slug":"pipeline-id","triggered_workflow":""}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{AppSlug: "app", APIToken: "token", APIBaseURL: server.URL, RetryCount: "0", PipelineID: "release"}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	targets, err := configs.triggerTargets()
+	require.NoError(t, err)
+	require.Equal(t, []TriggerTargetModel{{AppSlug: "app", APIToken: "token", PipelineID: "release"}}, targets)
+
+	result := triggerBuild(client, configs, targets[0])
+	require.NoError(t, result.err)
+	require.Equal(t, "release", requestModel.BuildParams.PipelineID)
+	require.Equal(t, "", requestModel.BuildParams.WorkflowID)
+	require.Equal(t, "pipeline-id", result.build.PipelineID)
+	require.Equal(t, "release", result.build.Pipeline)
+	require.Equal(t, server.URL+"/app/app/pipelines/pipeline-id", result.build.BuildURL)
+}
+
+func TestWaitForPipeline(t *testing.T) {
+	polls := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v0.1/apps/app/pipelines/pipeline-id", r.URL.Path)
+		polls++
+		status := "running"
+		if polls > 1 {
+			status = "failed"
+		}
+		_, err := w.Write([]byte(`{"id":"pipeline-id","name":"release","status":"` + status + `","stages":[
+			{"name":"build","status":"succeeded","workflows":[{"id":"w1","name":"build","status":"succeeded"}]},
+			{"name":"deploy","status":"` + status + `","workflows":[{"id":"w2","name":"deploy","status":"` + status + `"}]}
+		]}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", RetryCount: "0"})
+	require.NoError(t, err)
+
+	status, stages, err := waitForPipeline(context.Background(), client, "app", "pipeline-id", time.Millisecond)
+	require.NoError(t, err)
+	require.Equal(t, buildStatusFailed, status)
+	require.Equal(t, 2, polls)
+	require.Equal(t, "failed", stages[1].Workflows[0].Status)
+}
+
+func TestPipelineStatus(t *testing.T) {
+	for status, expected := range map[string]string{
+		"succeeded":            buildStatusSuccess,
+		"succeeded_with_abort": buildStatusAbortedWithSuccess,
+		"failed":               buildStatusFailed,
+		"aborted":              buildStatusAborted,
+	} {
+		actual, finished := pipelineStatus(status)
+		require.True(t, finished, status)
+		require.Equal(t, expected, actual, status)
+	}
+
+	_, finished := pipelineStatus("running")
+	require.False(t, finished)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+)
+
+const (
+	resultPolicyAll     = "all"
+	resultPolicyAny     = "any"
+	resultPolicyNone    = "none"
+	resultPolicyAtLeast = "at-least:"
+)
+
+// resultPolicy decides whether the step succeeds, based on the statuses of the triggered builds.
+type resultPolicy struct {
+	name          string
+	minSuccessful int
+}
+
+func parseResultPolicy(input string) (resultPolicy, error) {
+	switch {
+	case input == "" || input == resultPolicyAll:
+		return resultPolicy{name: resultPolicyAll}, nil
+	case input == resultPolicyAny:
+		return resultPolicy{name: resultPolicyAny, minSuccessful: 1}, nil
+	case input == resultPolicyNone:
+		return resultPolicy{name: resultPolicyNone}, nil
+	case strings.HasPrefix(input, resultPolicyAtLeast):
+		minSuccessful, err := strconv.Atoi(strings.TrimPrefix(input, resultPolicyAtLeast))
+		if err != nil || minSuccessful <= 0 {
+			return resultPolicy{}, fmt.Errorf("invalid result policy specified: %s, the number of builds must be a positive integer", input)
+		}
+		return resultPolicy{name: input, minSuccessful: minSuccessful}, nil
+	default:
+		return resultPolicy{}, fmt.Errorf("invalid result policy specified: %s, allowed: %s, %s, %s, %sN", input, resultPolicyAll, resultPolicyAny, resultPolicyNone, resultPolicyAtLeast)
+	}
+}
+
+// isSatisfied reports whether the finished builds satisfy the policy.
+func (policy resultPolicy) isSatisfied(builds []TriggeredBuildModel) bool {
+	successful := countSuccessfulBuilds(builds)
+
+	switch policy.name {
+	case resultPolicyAll:
+		return successful == len(builds)
+	case resultPolicyNone:
+		return true
+	default:
+		return successful >= policy.minSuccessful
+	}
+}
+
+func countSuccessfulBuilds(builds []TriggeredBuildModel) int {
+	successful := 0
+	for _, build := range builds {
+		if build.Status == buildStatusSuccess {
+			successful++
+		}
+	}
+	return successful
+}
+
+// printSummary prints a table of the finished builds and the result of the policy.
+func printSummary(builds []TriggeredBuildModel, policy resultPolicy) {
+	var buffer bytes.Buffer
+	writer := tabwriter.NewWriter(&buffer, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(writer, "WORKFLOW\tBUILD\tSTATUS\tURL")
+	for _, build := range builds {
+		fmt.Fprintf(writer, "%s\t#%d\t%s\t%s\n", buildDisplayName(build), build.BuildNumber, build.Status, build.BuildURL)
+		for _, stage := range build.Stages {
+			for _, workflow := range stage.Workflows {
+				fmt.Fprintf(writer, "  %s / %s\t\t%s\t\n", stage.Name, workflow.Name, stageStatus(workflow.Status))
+			}
+		}
+	}
+	if err := writer.Flush(); err != nil {
+		logger.Warnf("Failed to print summary, error: %s", err)
+		return
+	}
+
+	logger.Section()
+	logger.Infof("Summary:")
+	for _, line := range strings.Split(strings.TrimSuffix(buffer.String(), "\n"), "\n") {
+		logger.Printf("%s", line)
+	}
+	logger.Event(logLevelNormal, "summary", logFields{
+		"successful":    countSuccessfulBuilds(builds),
+		"total":         len(builds),
+		"result_policy": policy.name,
+		"satisfied":     policy.isSatisfied(builds),
+	}, "%d of %d build(s) succeeded, result policy: %s", countSuccessfulBuilds(builds), len(builds), policy.name)
+}
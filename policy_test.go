@@ -0,0 +1,70 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsInvalidResultPolicy(t *testing.T) {
+	for policy, expectedError := range map[string]string{
+		"most":       "invalid result policy specified: most, allowed: all, any, none, at-least:N",
+		"at-least:":  "invalid result policy specified: at-least:, the number of builds must be a positive integer",
+		"at-least:0": "invalid result policy specified: at-least:0, the number of builds must be a positive integer",
+		"at-least:x": "invalid result policy specified: at-least:x, the number of builds must be a positive integer",
+	} {
+		configs := ConfigsModel{
+			APIToken:     "token",
+			AppSlug:      "slug",
+			ResultPolicy: policy,
+		}
+		require.EqualError(t, configs.validate(), expectedError, policy)
+	}
+}
+
+func TestValidateConfigsUnsatisfiableResultPolicy(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AccessToken:  "access",
+		AppSlug:      "slug",
+		WorkflowID:   "unit-tests|ui-tests",
+		WaitForBuild: "yes",
+		PollInterval: "30",
+		ResultPolicy: "at-least:5",
+	}
+	require.EqualError(t, configs.validate(), "result policy at-least:5 cannot be satisfied, only 2 build(s) are triggered")
+
+	configs.Matrix = "FLAVOR=free|paid|pro"
+	require.NoError(t, configs.validate())
+
+	configs.Matrix = ""
+	configs.WaitForAllResults = "yes"
+	require.NoError(t, configs.validate())
+}
+
+func TestValidateTriggerTargetsUnsatisfiableResultPolicy(t *testing.T) {
+	configs := ConfigsModel{AppSlug: "slug", WaitForBuild: "yes", ResultPolicy: "at-least:2"}
+	require.EqualError(t, configs.validateTriggerTargets([]TriggerTargetModel{{AppSlug: "slug", WorkflowID: "lint"}}), "result policy at-least:2 cannot be satisfied, only 1 build(s) are triggered")
+}
+
+func TestResultPolicyIsSatisfied(t *testing.T) {
+	builds := []TriggeredBuildModel{
+		{Status: buildStatusSuccess},
+		{Status: buildStatusFailed},
+		{Status: buildStatusSuccess},
+	}
+
+	expectations := map[string]bool{
+		"":           false,
+		"all":        false,
+		"any":        true,
+		"none":       true,
+		"at-least:2": true,
+		"at-least:3": false,
+	}
+	for input, expected := range expectations {
+		policy, err := parseResultPolicy(input)
+		require.NoError(t, err)
+		require.Equal(t, expected, policy.isSatisfied(builds), input)
+	}
+}
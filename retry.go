@@ -0,0 +1,137 @@
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultRetryCount   = 3
+	retryInitialBackoff = 2 * time.Second
+	retryMaxBackoff     = 60 * time.Second
+)
+
+// doWithRetry sends the request and retries it on transient failures, at most retryCount times.
+// Requests which are not idempotent (e.g. the build trigger) are retried only if the server certainly did not
+// process them, so a retry can never start a duplicate build.
+func doWithRetry(client *http.Client, request *http.Request, retryCount int) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		if attempt > 0 && request.GetBody != nil {
+			body, err := request.GetBody()
+			if err != nil {
+				return nil, err
+			}
+			request.Body = body
+		}
+
+		logger.Event(logLevelNormal, "http_request", logFields{
+			"method":       request.Method,
+			"path":         request.URL.Path,
+			"attempt":      attempt + 1,
+			"max_attempts": retryCount + 1,
+		}, "%s %s (attempt %d/%d)", request.Method, request.URL.Path, attempt+1, retryCount+1)
+		response, err := client.Do(request)
+
+		var wait time.Duration
+		if err != nil {
+			if attempt >= retryCount || !isRetryableError(request, err) {
+				return nil, err
+			}
+			wait = backoffDuration(attempt)
+			logger.Warnf("Request failed, error: %s", err)
+		} else {
+			if attempt >= retryCount || !isRetryableStatus(request, response.StatusCode) {
+				return response, nil
+			}
+			wait = retryAfterDuration(response.Header.Get("Retry-After"), time.Now())
+			if wait <= 0 {
+				wait = backoffDuration(attempt)
+			}
+			logger.Warnf("Request failed, status: %s", response.Status)
+			if err := response.Body.Close(); err != nil {
+				logger.Warnf("Failed to close response body, error: %s", err)
+			}
+		}
+
+		logger.Warnf("Retrying in %s", wait)
+		select {
+		case <-request.Context().Done():
+			return nil, request.Context().Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// isRetryableError reports whether a request which failed with a network error can be sent again.
+// Only connection errors are retried for non-idempotent requests, because after the request
+// has been written the server may have already processed it.
+func isRetryableError(request *http.Request, err error) bool {
+	if isIdempotent(request) {
+		return true
+	}
+
+	var dnsError *net.DNSError
+	if errors.As(err, &dnsError) {
+		return true
+	}
+
+	var opError *net.OpError
+	return errors.As(err, &opError) && opError.Op == "dial"
+}
+
+// isRetryableStatus reports whether a response status means the request can be sent again.
+// For non-idempotent requests only statuses which guarantee that the request was rejected are retried.
+func isRetryableStatus(request *http.Request, statusCode int) bool {
+	switch statusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	case http.StatusInternalServerError, http.StatusBadGateway, http.StatusGatewayTimeout:
+		return isIdempotent(request)
+	default:
+		return false
+	}
+}
+
+func isIdempotent(request *http.Request) bool {
+	return request.Method == "GET" || request.Method == "HEAD"
+}
+
+// backoffDuration returns the exponential backoff for the given attempt with a random jitter of up to 50%.
+func backoffDuration(attempt int) time.Duration {
+	backoff := retryInitialBackoff << uint(attempt)
+	if backoff > retryMaxBackoff || backoff <= 0 {
+		backoff = retryMaxBackoff
+	}
+	return backoff/2 + time.Duration(rand.Int63n(int64(backoff/2)+1))
+}
+
+// retryAfterDuration parses the Retry-After header value, which is either a number of seconds or an HTTP date.
+// It returns 0 if the header is missing or invalid. The wait is limited to retryMaxBackoff,
+// so a large value does not stall the step.
+func retryAfterDuration(retryAfter string, now time.Time) time.Duration {
+	if retryAfter == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(retryAfter); err == nil {
+		if seconds < 0 {
+			return 0
+		} else if seconds > int(retryMaxBackoff/time.Second) {
+			return retryMaxBackoff
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if date, err := http.ParseTime(retryAfter); err == nil {
+		if wait := date.Sub(now); wait > retryMaxBackoff {
+			return retryMaxBackoff
+		} else if wait > 0 {
+			return wait
+		}
+	}
+	return 0
+}
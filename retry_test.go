@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestIsRetryableStatusPost(t *testing.T) {
+	request, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	require.True(t, isRetryableStatus(request, http.StatusTooManyRequests))
+	require.True(t, isRetryableStatus(request, http.StatusServiceUnavailable))
+	require.False(t, isRetryableStatus(request, http.StatusBadGateway))
+	require.False(t, isRetryableStatus(request, http.StatusGatewayTimeout))
+	require.False(t, isRetryableStatus(request, http.StatusCreated))
+}
+
+func TestIsRetryableStatusGet(t *testing.T) {
+	request, err := http.NewRequest("GET", "https://example.com", nil)
+	require.NoError(t, err)
+
+	require.True(t, isRetryableStatus(request, http.StatusBadGateway))
+	require.False(t, isRetryableStatus(request, http.StatusNotFound))
+}
+
+func TestIsRetryableErrorPost(t *testing.T) {
+	request, err := http.NewRequest("POST", "https://example.com", nil)
+	require.NoError(t, err)
+
+	require.True(t, isRetryableError(request, &net.OpError{Op: "dial", Err: errors.New("refused")}))
+	require.False(t, isRetryableError(request, &net.OpError{Op: "read", Err: errors.New("reset")}))
+	require.False(t, isRetryableError(request, errors.New("unexpected EOF")))
+}
+
+func TestRetryAfterDuration(t *testing.T) {
+	now := time.Date(2020, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	require.Equal(t, 5*time.Second, retryAfterDuration("5", now))
+	require.Equal(t, 10*time.Second, retryAfterDuration("Wed, 01 Jan 2020 12:00:10 GMT", now))
+	require.Equal(t, time.Duration(0), retryAfterDuration("", now))
+	require.Equal(t, time.Duration(0), retryAfterDuration("invalid", now))
+	require.Equal(t, retryMaxBackoff, retryAfterDuration("86400", now))
+	require.Equal(t, retryMaxBackoff, retryAfterDuration("99999999999999999", now))
+	require.Equal(t, retryMaxBackoff, retryAfterDuration("Thu, 01 Jan 2099 12:00:00 GMT", now))
+}
+
+func TestBackoffDuration(t *testing.T) {
+	for attempt := 0; attempt < 10; attempt++ {
+		backoff := backoffDuration(attempt)
+		require.True(t, backoff >= retryInitialBackoff/2)
+		require.True(t, backoff <= retryMaxBackoff)
+	}
+}
+
+func TestDoWithRetryHonorsRetryAfter(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		if attempts == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusCreated)
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequest("POST", server.URL, nil)
+	require.NoError(t, err)
+
+	response, err := doWithRetry(server.Client(), request, 1)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusCreated, response.StatusCode)
+	require.Equal(t, 2, attempts)
+}
+
+func TestDoWithRetryDoesNotRetryAcceptedRequest(t *testing.T) {
+	attempts := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		attempts++
+		w.WriteHeader(http.StatusGatewayTimeout)
+	}))
+	defer server.Close()
+
+	request, err := http.NewRequest("POST", server.URL, nil)
+	require.NoError(t, err)
+
+	response, err := doWithRetry(server.Client(), request, 3)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusGatewayTimeout, response.StatusCode)
+	require.Equal(t, 1, attempts)
+}
+
+func TestValidateConfigsInvalidRetryCount(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		RetryCount: "-1",
+	}
+	require.EqualError(t, configs.validate(), "invalid retry count specified: -1, must be a non-negative integer")
+}
+
+func TestRetryCountDefault(t *testing.T) {
+	configs := ConfigsModel{}
+	require.Equal(t, defaultRetryCount, configs.retryCount())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// parseAdditionalApps parses `|` separated `<app_slug>:<token_env_var>[:<workflow_id>,<workflow_id>...]` entries.
+func parseAdditionalApps(input string) ([]AdditionalAppModel, error) {
+	apps := []AdditionalAppModel{}
+
+	for _, entry := range splitPipeSeparatedStringArray(input) {
+		parts := strings.Split(entry, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			return apps, fmt.Errorf("invalid additional app specified: %s, expected format: <app_slug>:<token_env_var>[:<workflow_id>,...]", entry)
+		}
+
+		app := AdditionalAppModel{
+			AppSlug:     strings.TrimSpace(parts[0]),
+			TokenEnvVar: strings.TrimSpace(parts[1]),
+			WorkflowIDs: []string{},
+		}
+		if app.AppSlug == "" {
+			return apps, fmt.Errorf("empty app slug specified in additional app: %s", entry)
+		}
+
+		if len(parts) == 3 {
+			for _, workflowID := range strings.Split(parts[2], ",") {
+				workflowID = strings.TrimSpace(workflowID)
+				if workflowID == "" {
+					return apps, fmt.Errorf("empty workflow ID specified in additional app: %s", entry)
+				}
+				app.WorkflowIDs = append(app.WorkflowIDs, workflowID)
+			}
+		}
+
+		apps = append(apps, app)
+	}
+
+	return apps, nil
+}
+
+// triggerTargets returns a target for each workflow of each app to trigger, starting with the ones of the main app.
+// Additional apps without workflow IDs trigger the workflows of the main app, including the ones selected by the path rules.
+// If a matrix is configured, every target is triggered once for each matrix cell.
+// A pipeline is triggered by a single target on the main app.
+func (configs ConfigsModel) triggerTargets() ([]TriggerTargetModel, error) {
+	targets := []TriggerTargetModel{}
+	if configs.PipelineID != "" {
+		return append(targets, TriggerTargetModel{
+			AppSlug:    configs.AppSlug,
+			APIToken:   configs.APIToken,
+			PipelineID: configs.PipelineID,
+		}), nil
+	}
+
+	for _, workflowID := range configs.workflowIDs() {
+		targets = append(targets, TriggerTargetModel{
+			AppSlug:    configs.AppSlug,
+			APIToken:   configs.APIToken,
+			WorkflowID: workflowID,
+		})
+	}
+
+	apps, err := parseAdditionalApps(configs.AdditionalApps)
+	if err != nil {
+		return targets, err
+	}
+
+	for _, app := range apps {
+		workflowIDs := app.WorkflowIDs
+		if len(workflowIDs) == 0 {
+			workflowIDs = configs.workflowIDs()
+		}
+
+		apiToken := ""
+		if app.TokenEnvVar != "" {
+			apiToken = os.Getenv(app.TokenEnvVar)
+		}
+
+		for _, workflowID := range workflowIDs {
+			targets = append(targets, TriggerTargetModel{
+				AppSlug:    app.AppSlug,
+				APIToken:   apiToken,
+				WorkflowID: workflowID,
+			})
+		}
+	}
+
+	cells, err := expandMatrix(configs.Matrix, configs.MatrixInclude, configs.MatrixExclude)
+	if err != nil || len(cells) == 0 {
+		return targets, err
+	}
+
+	matrixTargets := []TriggerTargetModel{}
+	for _, target := range targets {
+		for i := range cells {
+			target.MatrixCell = &cells[i]
+			matrixTargets = append(matrixTargets, target)
+		}
+	}
+	return matrixTargets, nil
+}
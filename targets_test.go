@@ -0,0 +1,58 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseAdditionalApps(t *testing.T) {
+	apps, err := parseAdditionalApps("android:ANDROID_TOKEN:unit-tests,lint|backend:BACKEND_TOKEN")
+	require.NoError(t, err)
+	require.Equal(t, []AdditionalAppModel{
+		{AppSlug: "android", TokenEnvVar: "ANDROID_TOKEN", WorkflowIDs: []string{"unit-tests", "lint"}},
+		{AppSlug: "backend", TokenEnvVar: "BACKEND_TOKEN", WorkflowIDs: []string{}},
+	}, apps)
+}
+
+func TestParseAdditionalAppsInvalid(t *testing.T) {
+	for _, input := range []string{"android", ":TOKEN", "android:TOKEN:a,,b", "android:TOKEN:a:b"} {
+		_, err := parseAdditionalApps(input)
+		require.Error(t, err, input)
+	}
+}
+
+func TestValidateConfigsAdditionalAppWithoutToken(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:       "token",
+		AppSlug:        "slug",
+		AdditionalApps: "android:TRIGGER_STEP_TEST_MISSING_TOKEN",
+	}
+	require.EqualError(t, configs.validate(), "empty Build Trigger API token in environment variable TRIGGER_STEP_TEST_MISSING_TOKEN for app: android")
+}
+
+func TestTriggerTargets(t *testing.T) {
+	require.NoError(t, os.Setenv("TRIGGER_STEP_TEST_ANDROID_TOKEN", "android-token"))
+	defer func() {
+		require.NoError(t, os.Unsetenv("TRIGGER_STEP_TEST_ANDROID_TOKEN"))
+	}()
+
+	configs := ConfigsModel{
+		APIToken:       "token",
+		AppSlug:        "ios",
+		WorkflowID:     "tests|lint",
+		AdditionalApps: "android:TRIGGER_STEP_TEST_ANDROID_TOKEN:unit|backend:TRIGGER_STEP_TEST_ANDROID_TOKEN",
+	}
+	require.NoError(t, configs.validate())
+
+	targets, err := configs.triggerTargets()
+	require.NoError(t, err)
+	require.Equal(t, []TriggerTargetModel{
+		{AppSlug: "ios", APIToken: "token", WorkflowID: "tests"},
+		{AppSlug: "ios", APIToken: "token", WorkflowID: "lint"},
+		{AppSlug: "android", APIToken: "android-token", WorkflowID: "unit"},
+		{AppSlug: "backend", APIToken: "android-token", WorkflowID: "tests"},
+		{AppSlug: "backend", APIToken: "android-token", WorkflowID: "lint"},
+	}, targets)
+}
@@ -0,0 +1,175 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+)
+
+const (
+	triggerMapResolutionNone = "none"
+	triggerMapResolutionShow = "show"
+	triggerMapResolutionLock = "lock"
+)
+
+// BitriseYMLModel is the part of the bitrise.yml used by the step.
+// Workflows and pipelines keep the order they are defined in.
+type BitriseYMLModel struct {
+	TriggerMap []TriggerMapItemModel `yaml:"trigger_map"`
+	Workflows  yaml.MapSlice         `yaml:"workflows"`
+	Pipelines  yaml.MapSlice         `yaml:"pipelines"`
+}
+
+// TriggerMapItemModel ...
+type TriggerMapItemModel struct {
+	PushBranch              string `yaml:"push_branch"`
+	PullRequestSourceBranch string `yaml:"pull_request_source_branch"`
+	PullRequestTargetBranch string `yaml:"pull_request_target_branch"`
+	Tag                     string `yaml:"tag"`
+	WorkflowID              string `yaml:"workflow"`
+	PipelineID              string `yaml:"pipeline"`
+	Enabled                 *bool  `yaml:"enabled"`
+}
+
+// isDisabled returns true if the item is explicitly disabled, items are enabled by default.
+func (item TriggerMapItemModel) isDisabled() bool {
+	return item.Enabled != nil && !*item.Enabled
+}
+
+// readBitriseYML reads and parses the bitrise.yml at the given path.
+func readBitriseYML(pth string) (BitriseYMLModel, error) {
+	content, err := ioutil.ReadFile(pth)
+	if err != nil {
+		return BitriseYMLModel{}, err
+	}
+
+	config, err := parseBitriseYML(content)
+	if err != nil {
+		return config, fmt.Errorf("failed to parse %s: %s", pth, err)
+	}
+	return config, nil
+}
+
+func parseBitriseYML(content []byte) (BitriseYMLModel, error) {
+	var config BitriseYMLModel
+	err := yaml.Unmarshal(content, &config)
+	return config, err
+}
+
+// mapSliceKeys returns the keys of a YAML mapping in order.
+func mapSliceKeys(mapSlice yaml.MapSlice) []string {
+	keys := []string{}
+	for _, item := range mapSlice {
+		keys = append(keys, fmt.Sprint(item.Key))
+	}
+	return keys
+}
+
+// parseTriggerMap returns the trigger map items of a parsed bitrise.yml in order.
+func parseTriggerMap(config BitriseYMLModel) ([]TriggerMapItemModel, error) {
+	if config.TriggerMap == nil {
+		return nil, errors.New("no trigger_map defined")
+	}
+
+	for i, item := range config.TriggerMap {
+		if item.WorkflowID == "" && item.PipelineID == "" {
+			return nil, fmt.Errorf("trigger_map item #%d has no workflow", i+1)
+		}
+		if item.PushBranch == "" && item.PullRequestSourceBranch == "" && item.PullRequestTargetBranch == "" && item.Tag == "" {
+			return nil, fmt.Errorf("trigger_map item #%d has no push_branch, pull_request_source_branch, pull_request_target_branch or tag", i+1)
+		}
+	}
+	return config.TriggerMap, nil
+}
+
+// matchTriggerMap returns the first enabled trigger map item matching the event described by the configs, the same way
+// the server selects the workflow: a tag event if a tag is set, a pull request event if a pull request ID or destination
+// branch is set, a push event otherwise. The second return value is false if no item matches.
+func (configs ConfigsModel) matchTriggerMap(items []TriggerMapItemModel) (TriggerMapItemModel, bool) {
+	isTag := configs.Tag != ""
+	isPullRequest := !isTag && (configs.PullRequestID != "" || configs.BranchDest != "")
+
+	for _, item := range items {
+		if item.isDisabled() {
+			continue
+		}
+
+		var matched bool
+		switch {
+		case isTag:
+			matched = item.Tag != "" && matchTriggerPattern(item.Tag, configs.Tag)
+		case isPullRequest:
+			matched = (item.PullRequestSourceBranch != "" || item.PullRequestTargetBranch != "") &&
+				matchTriggerPattern(item.PullRequestSourceBranch, configs.Branch) &&
+				matchTriggerPattern(item.PullRequestTargetBranch, configs.BranchDest)
+		default:
+			matched = item.PushBranch != "" && matchTriggerPattern(item.PushBranch, configs.Branch)
+		}
+
+		if matched {
+			return item, true
+		}
+	}
+	return TriggerMapItemModel{}, false
+}
+
+// triggerEventDescription describes the event the trigger map is evaluated for.
+func (configs ConfigsModel) triggerEventDescription() string {
+	switch {
+	case configs.Tag != "":
+		return fmt.Sprintf("tag %s", configs.Tag)
+	case configs.PullRequestID != "" || configs.BranchDest != "":
+		return fmt.Sprintf("pull request %s -> %s", configs.Branch, configs.BranchDest)
+	default:
+		return fmt.Sprintf("push to %s", configs.Branch)
+	}
+}
+
+// matchTriggerPattern matches a trigger map pattern, where `*` matches any sequence of characters, including `/`.
+// An empty pattern matches everything.
+func matchTriggerPattern(pattern, value string) bool {
+	if pattern == "" {
+		return true
+	}
+
+	parts := strings.Split(pattern, "*")
+	if len(parts) == 1 {
+		return pattern == value
+	}
+	if !strings.HasPrefix(value, parts[0]) {
+		return false
+	}
+	value = value[len(parts[0]):]
+
+	last := parts[len(parts)-1]
+	for _, part := range parts[1 : len(parts)-1] {
+		index := strings.Index(value, part)
+		if index < 0 {
+			return false
+		}
+		value = value[index+len(part):]
+	}
+	return len(value) >= len(last) && strings.HasSuffix(value, last)
+}
+
+// resolveWorkflowFromTriggerMap predicts the workflow the server would select from the trigger map of the bitrise.yml.
+func resolveWorkflowFromTriggerMap(configs ConfigsModel) (TriggerMapItemModel, error) {
+	config, err := readBitriseYML(configs.BitriseYMLPath)
+	if err != nil {
+		return TriggerMapItemModel{}, err
+	}
+
+	items, err := parseTriggerMap(config)
+	if err != nil {
+		return TriggerMapItemModel{}, err
+	}
+
+	item, found := configs.matchTriggerMap(items)
+	if !found {
+		return TriggerMapItemModel{}, fmt.Errorf("no trigger_map item matches %s", configs.triggerEventDescription())
+	}
+	return item, nil
+}
@@ -0,0 +1,164 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+const testBitriseYML = `format_version: "11"
+default_step_lib_source: https://github.com/bitrise-io/bitrise-steplib.git
+
+trigger_map:
+- push_branch: master # release builds
+  workflow: deploy
+- push_branch: "feature/*"
+  workflow: primary
+- pull_request_source_branch: "*"
+  pull_request_target_branch: master
+  workflow: pr
+- tag: "v*"
+  workflow: release
+  enabled: false
+- tag: "*"
+  pipeline: release-pipeline
+
+workflows:
+  primary:
+    steps:
+    - script@1:
+        inputs:
+        - content: |-
+            #!/bin/bash
+            # not a comment: key
+            echo "- not an item"
+  deploy:
+    description: 'Deploys the app'
+  pr: {}
+`
+
+func TestParseBitriseYML(t *testing.T) {
+	config, err := parseBitriseYML([]byte(testBitriseYML))
+	require.NoError(t, err)
+	require.Len(t, config.TriggerMap, 5)
+	require.Equal(t, "master", config.TriggerMap[0].PushBranch)
+	require.True(t, config.TriggerMap[3].isDisabled())
+	require.False(t, config.TriggerMap[0].isDisabled())
+	require.Equal(t, []string{"primary", "deploy", "pr"}, mapSliceKeys(config.Workflows))
+}
+
+func TestParseBitriseYMLAnchorsAndFlowMaps(t *testing.T) {
+	config, err := parseBitriseYML([]byte(`format_version: "11"
+app:
+  envs: &envs
+  - PROJECT: app
+trigger_map:
+- {push_branch: master, workflow: deploy}
+- pull_request_source_branch: "*"
+  workflow: primary
+workflows:
+  _base: &base
+    envs: *envs
+  primary:
+    <<: *base
+  deploy:
+    <<: *base
+    description: Deploys the app
+`))
+	require.NoError(t, err)
+	require.Equal(t, "deploy", config.TriggerMap[0].WorkflowID)
+	require.Equal(t, "master", config.TriggerMap[0].PushBranch)
+	require.Equal(t, []string{"_base", "primary", "deploy"}, mapSliceKeys(config.Workflows))
+}
+
+func TestParseBitriseYMLInvalid(t *testing.T) {
+	_, err := parseBitriseYML([]byte("workflows:\n  primary:\n    steps: []\n   deploy: {}\n"))
+	require.Error(t, err)
+
+	_, err = parseBitriseYML([]byte("not a mapping\n"))
+	require.Error(t, err)
+}
+
+func TestMatchTriggerPattern(t *testing.T) {
+	require.True(t, matchTriggerPattern("*", "feature/login"))
+	require.True(t, matchTriggerPattern("", "master"))
+	require.True(t, matchTriggerPattern("feature/*", "feature/a/b"))
+	require.True(t, matchTriggerPattern("*-rc*", "1.0-rc2"))
+	require.True(t, matchTriggerPattern("a*a", "aa"))
+	require.False(t, matchTriggerPattern("a*a", "a"))
+	require.False(t, matchTriggerPattern("master", "master2"))
+	require.False(t, matchTriggerPattern("feature/*", "bugfix/login"))
+}
+
+func TestMatchTriggerMap(t *testing.T) {
+	config, err := parseBitriseYML([]byte(testBitriseYML))
+	require.NoError(t, err)
+	items, err := parseTriggerMap(config)
+	require.NoError(t, err)
+
+	for _, tc := range []struct {
+		configs  ConfigsModel
+		workflow string
+		pipeline string
+		found    bool
+	}{
+		{configs: ConfigsModel{Branch: "master"}, workflow: "deploy", found: true},
+		{configs: ConfigsModel{Branch: "feature/login"}, workflow: "primary", found: true},
+		{configs: ConfigsModel{Branch: "develop"}},
+		{configs: ConfigsModel{Branch: "feature/login", BranchDest: "master", PullRequestID: "1"}, workflow: "pr", found: true},
+		{configs: ConfigsModel{Branch: "feature/login", BranchDest: "develop"}},
+		{configs: ConfigsModel{Branch: "master", Tag: "v1.0.0"}, pipeline: "release-pipeline", found: true},
+	} {
+		item, found := tc.configs.matchTriggerMap(items)
+		require.Equal(t, tc.found, found, tc.configs.triggerEventDescription())
+		require.Equal(t, tc.workflow, item.WorkflowID, tc.configs.triggerEventDescription())
+		require.Equal(t, tc.pipeline, item.PipelineID, tc.configs.triggerEventDescription())
+	}
+}
+
+func TestResolveWorkflowFromTriggerMap(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte(testBitriseYML), 0600))
+
+	item, err := resolveWorkflowFromTriggerMap(ConfigsModel{BitriseYMLPath: pth, Branch: "master"})
+	require.NoError(t, err)
+	require.Equal(t, "deploy", item.WorkflowID)
+
+	_, err = resolveWorkflowFromTriggerMap(ConfigsModel{BitriseYMLPath: pth, Branch: "develop"})
+	require.EqualError(t, err, "no trigger_map item matches push to develop")
+}
+
+func TestValidateWorkflowsDefinedLockedWorkflow(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte("trigger_map:\n- push_branch: master\n  workflow: deplyo\nworkflows:\n  deploy: {}\n"), 0600))
+
+	configs := ConfigsModel{AppSlug: "slug", BitriseYMLPath: pth, Branch: "master", ValidateWorkflows: "yes"}
+	item, err := resolveWorkflowFromTriggerMap(configs)
+	require.NoError(t, err)
+	configs.WorkflowID = item.WorkflowID
+
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	require.EqualError(t, configs.validateWorkflowsDefined(client, nil), "workflow deplyo is not defined in the bitrise.yml of app slug, did you mean deploy?")
+}
+
+func TestValidateConfigsTriggerMapResolution(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:             "token",
+		AppSlug:              "slug",
+		TriggerMapResolution: triggerMapResolutionLock,
+	}
+	require.EqualError(t, configs.validate(), "no bitrise.yml path specified, it is required by the trigger map resolution")
+
+	configs.BitriseYMLPath = "bitrise.yml"
+	require.NoError(t, configs.validate())
+
+	configs.WorkflowID = "primary"
+	require.EqualError(t, configs.validate(), "workflow or pipeline ID specified, the trigger map can only be resolved if both of them are empty")
+
+	configs.WorkflowID = ""
+	configs.TriggerMapResolution = "guess"
+	require.EqualError(t, configs.validate(), "invalid trigger map resolution specified: guess, allowed: none, show, lock")
+}
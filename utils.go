@@ -1,9 +1,11 @@
 package main
 
 import (
+	"fmt"
 	"github.com/bitrise-io/go-utils/command"
 	"strings"
 	"os"
+	"strconv"
 )
 
 func exportEnvironmentWithEnvman(keyStr, valueStr string) error {
@@ -25,9 +27,38 @@ func createExportedEnvironment(variableNames string) []EnvironmentVariableModel
 	return environments
 }
 
+func containsEnvironment(environments []EnvironmentVariableModel, key string) bool {
+	for _, environment := range environments {
+		if environment.MappedTo == key {
+			return true
+		}
+	}
+	return false
+}
+
+func sliceContains(values []string, value string) bool {
+	for _, item := range values {
+		if item == value {
+			return true
+		}
+	}
+	return false
+}
+
 func splitPipeSeparatedStringArray(input string) []string {
 	if input == "" {
 		return []string{}
 	}
 	return strings.Split(input, "|")
-}
\ No newline at end of file
+}
+
+func parsePositiveInt(input string) (int, error) {
+	value, err := strconv.Atoi(input)
+	if err != nil {
+		return 0, err
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("value must be positive, got: %d", value)
+	}
+	return value, nil
+}
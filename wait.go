@@ -0,0 +1,180 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+const (
+	defaultPollInterval = 30 * time.Second
+
+	buildStatusSuccess = "success"
+	buildStatusFailed  = "failed"
+	buildStatusAborted = "aborted"
+
+	buildStatusAbortedWithSuccess = "aborted_with_success"
+	buildStatusUnknown            = "unknown"
+
+	buildStatusAbortedFailFast = "aborted_fail_fast"
+)
+
+// waitForBuilds waits concurrently for every build to finish and returns them with their final status.
+// If ctx is cancelled, builds which have not finished yet are returned with an empty status.
+// In fail-fast mode the first build which does not succeed aborts every other build which is still running,
+// except the reused builds, which were started by someone else and are no longer waited for.
+func waitForBuilds(ctx context.Context, client apiClient, configs ConfigsModel, builds []TriggeredBuildModel) ([]TriggeredBuildModel, error) {
+	finishedBuilds := make([]TriggeredBuildModel, len(builds))
+	errs := make([]error, len(builds))
+
+	waitCtx, cancelWait := context.WithCancel(ctx)
+	defer cancelWait()
+
+	var failFastOnce sync.Once
+	var failedBuild *TriggeredBuildModel
+
+	var wg sync.WaitGroup
+	for i, build := range builds {
+		wg.Add(1)
+		go func(i int, build TriggeredBuildModel) {
+			defer wg.Done()
+			if build.PipelineID != "" {
+				build.Status, build.Stages, errs[i] = waitForPipeline(waitCtx, client, build.AppSlug, build.PipelineID, configs.pollInterval())
+			} else {
+				var tailer *buildLogTailer
+				if configs.BuildLogMode == buildLogModeAlways {
+					tailer = newBuildLogTailer(client, build)
+				}
+				build.Status, errs[i] = waitForBuild(waitCtx, client, build.AppSlug, build.BuildSlug, configs.pollInterval(), tailer)
+			}
+			finishedBuilds[i] = build
+
+			if configs.isFailFast() && errs[i] == nil && build.Status != buildStatusSuccess {
+				failFastOnce.Do(func() {
+					failedBuild = &build
+					cancelWait()
+				})
+			}
+		}(i, build)
+	}
+	wg.Wait()
+
+	if failedBuild != nil && ctx.Err() == nil {
+		logger.Warnf("Build %s (%s) finished with status: %s, aborting the other builds", failedBuild.BuildSlug, failedBuild.WorkflowID, failedBuild.Status)
+		reason := fmt.Sprintf("Aborted by fail-fast: build #%d (%s) finished with status: %s", failedBuild.BuildNumber, failedBuild.WorkflowID, failedBuild.Status)
+		abortBuilds(client, finishedBuilds, reason)
+
+		for i := range finishedBuilds {
+			// Only the waits cancelled by fail-fast are expected to fail, other errors are kept.
+			if finishedBuilds[i].Status != "" || !errors.Is(errs[i], context.Canceled) {
+				continue
+			}
+			if !finishedBuilds[i].Reused {
+				finishedBuilds[i].Status = buildStatusAbortedFailFast
+			}
+			errs[i] = nil
+		}
+	}
+
+	for _, err := range errs {
+		if err != nil {
+			return finishedBuilds, err
+		}
+	}
+	return finishedBuilds, nil
+}
+
+// firstResults returns the first build started by each trigger request, leaving out the other results.
+func firstResults(builds []TriggeredBuildModel) []TriggeredBuildModel {
+	first := []TriggeredBuildModel{}
+	for _, build := range builds {
+		if build.ResultIndex == 0 {
+			first = append(first, build)
+		}
+	}
+	return first
+}
+
+// updateBuildStatuses returns the builds with the status (and pipeline stages) of the matching finished builds.
+func updateBuildStatuses(builds, finishedBuilds []TriggeredBuildModel) []TriggeredBuildModel {
+	updated := append([]TriggeredBuildModel{}, builds...)
+	for i, build := range updated {
+		for _, finishedBuild := range finishedBuilds {
+			if finishedBuild.AppSlug == build.AppSlug && finishedBuild.BuildSlug == build.BuildSlug && finishedBuild.PipelineID == build.PipelineID {
+				updated[i].Status = finishedBuild.Status
+				updated[i].Stages = finishedBuild.Stages
+			}
+		}
+	}
+	return updated
+}
+
+// waitForBuild polls the build until it finishes. If tailer is not nil, the build log is printed meanwhile.
+func waitForBuild(ctx context.Context, client apiClient, appSlug, buildSlug string, pollInterval time.Duration, tailer *buildLogTailer) (string, error) {
+	for {
+		build, err := fetchBuild(ctx, client, appSlug, buildSlug)
+		if err != nil {
+			return "", err
+		}
+
+		status, finished := buildStatusFromCode(build.Status)
+		if status == buildStatusUnknown {
+			logger.Warnf("Build %s finished with unknown status code: %d", buildSlug, build.Status)
+		}
+		if tailer != nil {
+			if finished {
+				err = tailer.finish(ctx)
+			} else {
+				err = tailer.printNewChunks(ctx)
+			}
+			if err != nil {
+				logger.Warnf("Could not get log of build %s, error: %s", buildSlug, err)
+			}
+		}
+
+		if finished {
+			return status, nil
+		}
+
+		if tailer == nil {
+			logger.Event(logLevelNormal, "build_status", logFields{
+				"app_slug":      appSlug,
+				"build_slug":    buildSlug,
+				"status":        "running",
+				"poll_interval": pollInterval.Seconds(),
+			}, "Build %s is still running, checking again in %s", buildSlug, pollInterval)
+		}
+		select {
+		case <-ctx.Done():
+			return "", ctx.Err()
+		case <-time.After(pollInterval):
+		}
+	}
+}
+
+func fetchBuild(ctx context.Context, client apiClient, appSlug, buildSlug string) (BuildModel, error) {
+	var responseModel BuildStatusResponseModel
+	err := client.sendRESTRequest(ctx, "GET", fmt.Sprintf("/v0.1/apps/%s/builds/%s", appSlug, buildSlug), nil, &responseModel)
+	return responseModel.Data, err
+}
+
+// buildStatusFromCode maps the status code of the Bitrise API to the step's status names.
+// The second return value is false while the build is still running. Unknown codes are reported as the unknown status.
+func buildStatusFromCode(code int) (string, bool) {
+	switch code {
+	case 0:
+		return "", false
+	case 1:
+		return buildStatusSuccess, true
+	case 2:
+		return buildStatusFailed, true
+	case 3:
+		return buildStatusAborted, true
+	case 4:
+		return buildStatusAbortedWithSuccess, true
+	default:
+		return buildStatusUnknown, true
+	}
+}
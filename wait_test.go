@@ -0,0 +1,218 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsWaitWithoutAccessToken(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		WaitForBuild: "yes",
+		PollInterval: "30",
+	}
+	require.EqualError(t, configs.validate(), "empty Access token specified, it is required when waiting for the triggered build")
+}
+
+func TestValidateConfigsWaitInvalidPollInterval(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		WaitForBuild: "yes",
+		AccessToken:  "access",
+		PollInterval: "0",
+	}
+	require.EqualError(t, configs.validate(), "invalid poll interval specified: value must be positive, got: 0")
+}
+
+func TestValidateConfigsWaitValid(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		WaitForBuild: "yes",
+		AccessToken:  "access",
+		PollInterval: "10",
+	}
+	require.NoError(t, configs.validate())
+	require.Equal(t, 10*time.Second, configs.pollInterval())
+}
+
+func TestBuildStatusFromCode(t *testing.T) {
+	_, finished := buildStatusFromCode(0)
+	require.False(t, finished)
+
+	status, finished := buildStatusFromCode(1)
+	require.True(t, finished)
+	require.Equal(t, buildStatusSuccess, status)
+
+	status, _ = buildStatusFromCode(2)
+	require.Equal(t, buildStatusFailed, status)
+
+	status, _ = buildStatusFromCode(3)
+	require.Equal(t, buildStatusAborted, status)
+
+	status, _ = buildStatusFromCode(4)
+	require.Equal(t, buildStatusAbortedWithSuccess, status)
+
+	status, finished = buildStatusFromCode(5)
+	require.True(t, finished)
+	require.Equal(t, buildStatusUnknown, status)
+}
+
+func TestWaitForBuildCancelled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, err := w.Write([]byte(`{"data":{"status":0}}`))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client, err := newAPIClient(ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", RetryCount: "0"})
+	require.NoError(t, err)
+	_, err = waitForBuild(ctx, client, "app", "build", time.Minute, nil)
+	require.Error(t, err)
+}
+
+func TestValidateConfigsFailFastRequiresAllPolicy(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:     "token",
+		AppSlug:      "slug",
+		FailFast:     "yes",
+		ResultPolicy: "any",
+	}
+	require.EqualError(t, configs.validate(), "fail fast can be used only with the all result policy")
+}
+
+func TestWaitForBuildsFailFast(t *testing.T) {
+	abortedPaths := []string{}
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		var err error
+		switch r.URL.Path {
+		case "/v0.1/apps/app/builds/failing":
+			_, err = w.Write([]byte(`{"data":{"status":2}}`))
+		case "/v0.1/apps/app/builds/running":
+			_, err = w.Write([]byte(`{"data":{"status":0}}`))
+		default:
+			abortedPaths = append(abortedPaths, r.URL.Path)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", AppSlug: "app", PollInterval: "60", FailFast: "yes"}
+	builds := []TriggeredBuildModel{{AppSlug: "app", BuildSlug: "failing"}, {AppSlug: "app", BuildSlug: "running"}}
+
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	finishedBuilds, err := waitForBuilds(context.Background(), client, configs, builds)
+	require.NoError(t, err)
+	require.Equal(t, buildStatusFailed, finishedBuilds[0].Status)
+	require.Equal(t, buildStatusAbortedFailFast, finishedBuilds[1].Status)
+	require.Equal(t, []string{"/v0.1/apps/app/builds/running/abort"}, abortedPaths)
+}
+
+func TestWaitForBuildsFailFastSkipsReusedBuilds(t *testing.T) {
+	abortedPaths := []string{}
+	var mutex sync.Mutex
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mutex.Lock()
+		defer mutex.Unlock()
+
+		var err error
+		switch r.URL.Path {
+		case "/v0.1/apps/app/builds/failing":
+			_, err = w.Write([]byte(`{"data":{"status":2}}`))
+		case "/v0.1/apps/app/builds/running", "/v0.1/apps/app/builds/reused":
+			_, err = w.Write([]byte(`{"data":{"status":0}}`))
+		default:
+			abortedPaths = append(abortedPaths, r.URL.Path)
+		}
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", AppSlug: "app", PollInterval: "60", FailFast: "yes"}
+	builds := []TriggeredBuildModel{
+		{AppSlug: "app", BuildSlug: "failing"},
+		{AppSlug: "app", BuildSlug: "running"},
+		{AppSlug: "app", BuildSlug: "reused", Reused: true},
+	}
+
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	finishedBuilds, err := waitForBuilds(context.Background(), client, configs, builds)
+	require.NoError(t, err)
+	require.Equal(t, buildStatusAbortedFailFast, finishedBuilds[1].Status)
+	require.Equal(t, "", finishedBuilds[2].Status)
+	require.Equal(t, []string{"/v0.1/apps/app/builds/running/abort"}, abortedPaths)
+}
+
+func TestWaitForBuildsFailFastKeepsWaitErrors(t *testing.T) {
+	unauthorizedServed := make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v0.1/apps/app/builds/failing":
+			<-unauthorizedServed
+			time.Sleep(100 * time.Millisecond)
+			_, err := w.Write([]byte(`{"data":{"status":2}}`))
+			require.NoError(t, err)
+		case "/v0.1/apps/app/builds/unauthorized":
+			w.WriteHeader(http.StatusUnauthorized)
+			close(unauthorizedServed)
+		}
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{APIBaseURL: server.URL, AccessToken: "access", AppSlug: "app", PollInterval: "60", FailFast: "yes", RetryCount: "0"}
+	builds := []TriggeredBuildModel{{AppSlug: "app", BuildSlug: "failing"}, {AppSlug: "app", BuildSlug: "unauthorized"}}
+
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+
+	finishedBuilds, err := waitForBuilds(context.Background(), client, configs, builds)
+	require.Error(t, err)
+	require.Equal(t, errorCategoryAuth, errorCategory(err))
+	require.Equal(t, buildStatusFailed, finishedBuilds[0].Status)
+	require.Equal(t, "", finishedBuilds[1].Status)
+}
+
+func TestValidateConfigsFailFastRequiresWait(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken: "token",
+		AppSlug:  "slug",
+		FailFast: "yes",
+	}
+	require.EqualError(t, configs.validate(), "fail fast can be used only when waiting for the triggered builds")
+}
+
+func TestFirstResultsAndUpdateBuildStatuses(t *testing.T) {
+	builds := []TriggeredBuildModel{
+		{AppSlug: "app", BuildSlug: "first"},
+		{AppSlug: "app", BuildSlug: "second", ResultIndex: 1},
+		{AppSlug: "other", BuildSlug: "third"},
+	}
+
+	first := firstResults(builds)
+	require.Equal(t, []string{"first", "third"}, []string{first[0].BuildSlug, first[1].BuildSlug})
+
+	first[0].Status = buildStatusSuccess
+	first[1].Status = buildStatusFailed
+	updated := updateBuildStatuses(builds, first)
+	require.Equal(t, []string{buildStatusSuccess, "", buildStatusFailed}, []string{updated[0].Status, updated[1].Status, updated[2].Status})
+	require.Equal(t, "", builds[0].Status)
+}
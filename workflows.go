@@ -0,0 +1,135 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"strings"
+)
+
+// loadBitriseYML loads the content of the bitrise.yml of the app from the local path, if specified, or from the app config API.
+// Failures of the API request keep their category (e.g. auth or network).
+func (configs ConfigsModel) loadBitriseYML(client apiClient) ([]byte, error) {
+	if configs.BitriseYMLPath != "" {
+		content, err := ioutil.ReadFile(configs.BitriseYMLPath)
+		if err != nil {
+			return nil, newStepError(errorCategoryConfig, "could not read bitrise.yml: %s", err)
+		}
+		return content, nil
+	}
+
+	content, err := client.doRESTRequest(context.Background(), "GET", fmt.Sprintf("/v0.1/apps/%s/bitrise.yml", configs.AppSlug), nil, nil)
+	if err != nil {
+		return nil, categorize(errorCategoryNetwork, fmt.Errorf("could not download bitrise.yml: %w", err))
+	}
+	return content, nil
+}
+
+// validateWorkflowsDefined checks that every workflow the step may trigger on the main app is defined in its bitrise.yml.
+func (configs ConfigsModel) validateWorkflowsDefined(client apiClient, rules []PathRuleModel) error {
+	content, err := configs.loadBitriseYML(client)
+	if err != nil {
+		return fmt.Errorf("could not load bitrise.yml to validate the workflows: %w", err)
+	}
+
+	config, err := parseBitriseYML(content)
+	if err != nil {
+		return newStepError(errorCategoryConfig, "could not parse the bitrise.yml of app %s to validate the workflows: %s", configs.AppSlug, err)
+	}
+
+	if configs.PipelineID != "" {
+		pipelineIDs := mapSliceKeys(config.Pipelines)
+		if len(pipelineIDs) == 0 {
+			return fmt.Errorf("no pipelines defined in the bitrise.yml of app %s", configs.AppSlug)
+		} else if !sliceContains(pipelineIDs, configs.PipelineID) {
+			return fmt.Errorf("pipeline %s is not defined in the bitrise.yml of app %s, defined pipelines: %s", configs.PipelineID, configs.AppSlug, strings.Join(pipelineIDs, ", "))
+		}
+		return nil
+	}
+
+	definedWorkflowIDs := mapSliceKeys(config.Workflows)
+	if len(definedWorkflowIDs) == 0 {
+		return fmt.Errorf("no workflows defined in the bitrise.yml of app %s", configs.AppSlug)
+	}
+
+	workflowIDs := splitPipeSeparatedStringArray(configs.WorkflowID)
+	for _, rule := range rules {
+		workflowIDs = append(workflowIDs, rule.WorkflowIDs...)
+	}
+
+	for _, workflowID := range workflowIDs {
+		if sliceContains(definedWorkflowIDs, workflowID) {
+			continue
+		}
+
+		if suggestion := closestWorkflowID(workflowID, definedWorkflowIDs); suggestion != "" {
+			return fmt.Errorf("workflow %s is not defined in the bitrise.yml of app %s, did you mean %s?", workflowID, configs.AppSlug, suggestion)
+		}
+		return fmt.Errorf("workflow %s is not defined in the bitrise.yml of app %s, defined workflows: %s", workflowID, configs.AppSlug, strings.Join(triggerableWorkflowIDs(definedWorkflowIDs), ", "))
+	}
+	return nil
+}
+
+func isUtilityWorkflow(workflowID string) bool {
+	return strings.HasPrefix(workflowID, "_")
+}
+
+func triggerableWorkflowIDs(workflowIDs []string) []string {
+	triggerable := []string{}
+	for _, workflowID := range workflowIDs {
+		if !isUtilityWorkflow(workflowID) {
+			triggerable = append(triggerable, workflowID)
+		}
+	}
+	return triggerable
+}
+
+// closestWorkflowID returns the triggerable workflow closest to workflowID by edit distance,
+// or an empty string if none of them is close enough to be a likely typo.
+func closestWorkflowID(workflowID string, workflowIDs []string) string {
+	maxDistance := len(workflowID) / 3
+	if maxDistance < 2 {
+		maxDistance = 2
+	}
+
+	closest := ""
+	closestDistance := maxDistance + 1
+	for _, candidate := range triggerableWorkflowIDs(workflowIDs) {
+		if distance := levenshteinDistance(strings.ToLower(workflowID), strings.ToLower(candidate)); distance < closestDistance {
+			closest, closestDistance = candidate, distance
+		}
+	}
+	return closest
+}
+
+func levenshteinDistance(a, b string) int {
+	source, target := []rune(a), []rune(b)
+	previous := make([]int, len(target)+1)
+	current := make([]int, len(target)+1)
+	for j := range previous {
+		previous[j] = j
+	}
+
+	for i := 1; i <= len(source); i++ {
+		current[0] = i
+		for j := 1; j <= len(target); j++ {
+			cost := 1
+			if source[i-1] == target[j-1] {
+				cost = 0
+			}
+			current[j] = minInt(previous[j]+1, current[j-1]+1, previous[j-1]+cost)
+		}
+		previous, current = current, previous
+	}
+	return previous[len(target)]
+}
+
+func minInt(first int, others ...int) int {
+	min := first
+	for _, value := range others {
+		if value < min {
+			min = value
+		}
+	}
+	return min
+}
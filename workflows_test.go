@@ -0,0 +1,128 @@
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateConfigsUtilityWorkflow(t *testing.T) {
+	configs := ConfigsModel{
+		APIToken:   "token",
+		AppSlug:    "slug",
+		WorkflowID: "primary|_setup",
+	}
+	require.EqualError(t, configs.validate(), "utility workflow specified: _setup, workflows starting with _ cannot be triggered")
+}
+
+func TestValidateWorkflowsDefinedLocalBitriseYML(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte(testBitriseYML), 0600))
+
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WorkflowID:        "primery",
+		BitriseYMLPath:    pth,
+		ValidateWorkflows: "yes",
+	}
+	require.NoError(t, configs.validate())
+
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	require.EqualError(t, configs.validateWorkflowsDefined(client, nil), "workflow primery is not defined in the bitrise.yml of app slug, did you mean primary?")
+
+	configs.WorkflowID = "nightly"
+	require.EqualError(t, configs.validateWorkflowsDefined(client, nil), "workflow nightly is not defined in the bitrise.yml of app slug, defined workflows: primary, deploy, pr")
+
+	configs.WorkflowID = "primary|deploy"
+	require.NoError(t, configs.validateWorkflowsDefined(client, nil))
+}
+
+func TestValidateWorkflowsDefinedUnparsableBitriseYML(t *testing.T) {
+	pth := filepath.Join(t.TempDir(), "bitrise.yml")
+	require.NoError(t, ioutil.WriteFile(pth, []byte("workflows: [unclosed\n"), 0600))
+
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AppSlug:           "slug",
+		WorkflowID:        "primery",
+		BitriseYMLPath:    pth,
+		ValidateWorkflows: "yes",
+	}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	err = configs.validateWorkflowsDefined(client, nil)
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "could not parse the bitrise.yml of app slug to validate the workflows")
+	require.Equal(t, errorCategoryConfig, errorCategory(err))
+
+	configs.BitriseYMLPath = filepath.Join(t.TempDir(), "missing.yml")
+	err = configs.validateWorkflowsDefined(client, nil)
+	require.Error(t, err)
+	require.Equal(t, errorCategoryConfig, errorCategory(err))
+}
+
+func TestValidateWorkflowsDefinedAppConfigAPI(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.Equal(t, "/v0.1/apps/slug/bitrise.yml", r.URL.Path)
+		require.Equal(t, "access", r.Header.Get("Authorization"))
+		_, err := w.Write([]byte(testBitriseYML))
+		require.NoError(t, err)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AccessToken:       "access",
+		AppSlug:           "slug",
+		APIBaseURL:        server.URL,
+		WorkflowID:        "primary",
+		PathRules:         "server/**=deplyo",
+		BranchDest:        "master",
+		ValidateWorkflows: "yes",
+		RetryCount:        "0",
+	}
+	require.NoError(t, configs.validate())
+
+	rules, err := parsePathRules(configs.PathRules)
+	require.NoError(t, err)
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	require.EqualError(t, configs.validateWorkflowsDefined(client, rules), "workflow deplyo is not defined in the bitrise.yml of app slug, did you mean deploy?")
+}
+
+func TestValidateWorkflowsDefinedAppConfigAPIFailure(t *testing.T) {
+	status := http.StatusUnauthorized
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(status)
+	}))
+	defer server.Close()
+
+	configs := ConfigsModel{
+		APIToken:          "token",
+		AccessToken:       "access",
+		AppSlug:           "slug",
+		APIBaseURL:        server.URL,
+		WorkflowID:        "primary",
+		ValidateWorkflows: "yes",
+		RetryCount:        "0",
+	}
+	client, err := newAPIClient(configs)
+	require.NoError(t, err)
+	require.Equal(t, errorCategoryAuth, errorCategory(configs.validateWorkflowsDefined(client, nil)))
+
+	status = http.StatusInternalServerError
+	require.Equal(t, errorCategoryNetwork, errorCategory(configs.validateWorkflowsDefined(client, nil)))
+}
+
+func TestLevenshteinDistance(t *testing.T) {
+	require.Equal(t, 0, levenshteinDistance("primary", "primary"))
+	require.Equal(t, 1, levenshteinDistance("primery", "primary"))
+	require.Equal(t, 3, levenshteinDistance("kitten", "sitting"))
+	require.Equal(t, 4, levenshteinDistance("", "test"))
+}